@@ -0,0 +1,91 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/coderanger/controller-utils/conditions"
+)
+
+// RabbitBindingSpec defines the desired state of RabbitBinding
+type RabbitBindingSpec struct {
+	Vhost string `json:"vhost"`
+	// Name of the source exchange.
+	Source string `json:"source"`
+	// Name of the destination queue or exchange.
+	Destination string `json:"destination"`
+	// Type of the destination: queue or exchange. Defaults to queue.
+	DestinationType string `json:"destinationType,omitempty"`
+	RoutingKey      string `json:"routingKey,omitempty"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Arguments  *runtime.RawExtension `json:"arguments,omitempty"`
+	Connection RabbitConnection      `json:"connection,omitempty"`
+}
+
+// Identity of a binding on the RabbitMQ server.
+type RabbitBindingRef struct {
+	Vhost           string `json:"vhost"`
+	Source          string `json:"source"`
+	Destination     string `json:"destination"`
+	DestinationType string `json:"destinationType"`
+	PropertiesKey   string `json:"propertiesKey"`
+}
+
+// RabbitBindingStatus defines the observed state of RabbitBinding
+type RabbitBindingStatus struct {
+	// The binding last created for this object, used to clean up stale bindings when the spec changes.
+	Binding *RabbitBindingRef `json:"binding,omitempty"`
+
+	// Represents the observations of a RabbitBinding's current state.
+	// Known .status.conditions.type are: Ready, BindingReady
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []conditions.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// RabbitBinding is the Schema for the rabbitbindings API
+type RabbitBinding struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RabbitBindingSpec   `json:"spec,omitempty"`
+	Status RabbitBindingStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RabbitBindingList contains a list of RabbitBinding
+type RabbitBindingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RabbitBinding `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RabbitBinding{}, &RabbitBindingList{})
+}
+
+// TODO code generator for this.
+func (o *RabbitBinding) GetConditions() *[]conditions.Condition {
+	return &o.Status.Conditions
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var rabbitBindingLog = logf.Log.WithName("webhooks").WithName("rabbitbinding")
+
+// +kubebuilder:webhook:path=/mutate-rabbitmq-coderanger-net-v1beta1-rabbitbinding,mutating=true,failurePolicy=fail,sideEffects=None,groups=rabbitmq.coderanger.net,resources=rabbitbindings,verbs=create;update,versions=v1beta1,name=mrabbitbinding.kb.io,admissionReviewVersions=v1beta1
+
+var _ webhook.Defaulter = &RabbitBinding{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (obj *RabbitBinding) Default() {
+	rabbitBindingLog.Info("default", "name", obj.Name, "namespace", obj.Namespace)
+
+	if obj.Spec.DestinationType == "" {
+		obj.Spec.DestinationType = "queue"
+	}
+}
+
+// +kubebuilder:webhook:path=/validate-rabbitmq-coderanger-net-v1beta1-rabbitbinding,mutating=true,failurePolicy=fail,sideEffects=None,groups=rabbitmq.coderanger.net,resources=rabbitbindings,verbs=create;update,versions=v1beta1,name=vrabbitbinding.kb.io,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &RabbitBinding{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (obj *RabbitBinding) ValidateCreate() error {
+	rabbitBindingLog.Info("validate create", "name", obj.Name, "namespace", obj.Namespace)
+	return obj.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (obj *RabbitBinding) ValidateUpdate(old runtime.Object) error {
+	rabbitBindingLog.Info("validate update", "name", obj.Name, "namespace", obj.Namespace)
+	return obj.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type. Not used, just here for interface compliance.
+func (obj *RabbitBinding) ValidateDelete() error {
+	return nil
+}
+
+func (obj *RabbitBinding) validate() error {
+	err := ValidateConnection(&obj.Spec.Connection)
+	if err != nil {
+		return err
+	}
+
+	// The default exchange can't have explicit bindings.
+	if obj.Spec.Source == "" {
+		return errors.New("source exchange is required")
+	}
+	if obj.Spec.Destination == "" {
+		return errors.New("destination is required")
+	}
+	switch obj.Spec.DestinationType {
+	case "queue", "exchange":
+	default:
+		return errors.Errorf("destination type %s is not a known type", obj.Spec.DestinationType)
+	}
+	return validateArguments(obj.Spec.Arguments)
+}
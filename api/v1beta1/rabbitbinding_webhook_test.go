@@ -0,0 +1,75 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("RabbitBinding Webhook", func() {
+	var obj *RabbitBinding
+
+	BeforeEach(func() {
+		obj = &RabbitBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing", Namespace: "default"},
+			Spec: RabbitBindingSpec{
+				Vhost:           "/",
+				Source:          "myexchange",
+				Destination:     "myqueue",
+				DestinationType: "queue",
+			},
+		}
+	})
+
+	Describe("Default", func() {
+		It("sets the destination type if unset", func() {
+			obj.Spec.DestinationType = ""
+			obj.Default()
+			Expect(obj.Spec.DestinationType).To(Equal("queue"))
+		})
+
+		It("does not set the destination type if set", func() {
+			obj.Spec.DestinationType = "exchange"
+			obj.Default()
+			Expect(obj.Spec.DestinationType).To(Equal("exchange"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts a simple object", func() {
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+			err = obj.ValidateUpdate(obj)
+			Expect(err).ToNot(HaveOccurred())
+			err = obj.ValidateDelete()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects a binding from the default exchange", func() {
+			obj.Spec.Source = ""
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("source exchange is required"))
+		})
+
+		It("rejects an unknown destination type", func() {
+			obj.Spec.DestinationType = "stream"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("destination type stream is not a known type"))
+		})
+	})
+})
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/coderanger/controller-utils/conditions"
+)
+
+type RabbitClusterTLS struct {
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+	// PEM CA bundle used to verify the management API certificate. Key defaults to ca.crt.
+	CASecretRef *ClusterSecretRef `json:"caSecretRef,omitempty"`
+}
+
+// RabbitClusterSpec defines the desired state of RabbitCluster
+type RabbitClusterSpec struct {
+	// Management API protocol, http or https. Defaults to http.
+	Protocol string `json:"protocol,omitempty"`
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"`
+	// Admin user for the management API.
+	Username string `json:"username"`
+	// Key defaults to password.
+	PasswordSecretRef *ClusterSecretRef `json:"passwordSecretRef,omitempty"`
+	TLS               *RabbitClusterTLS `json:"tls,omitempty"`
+}
+
+// RabbitClusterStatus defines the observed state of RabbitCluster
+type RabbitClusterStatus struct {
+	// Represents the observations of a RabbitCluster's current state.
+	// Known .status.conditions.type are: Ready, ClusterReady
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []conditions.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+	// Broker version as reported by the management API.
+	RabbitMQVersion string `json:"rabbitmqVersion,omitempty"`
+	ErlangVersion   string `json:"erlangVersion,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Host",type=string,JSONPath=".spec.host"
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=".status.rabbitmqVersion"
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// RabbitCluster is the Schema for the rabbitclusters API
+type RabbitCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RabbitClusterSpec   `json:"spec,omitempty"`
+	Status RabbitClusterStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RabbitClusterList contains a list of RabbitCluster
+type RabbitClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RabbitCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RabbitCluster{}, &RabbitClusterList{})
+}
+
+// TODO code generator for this.
+func (o *RabbitCluster) GetConditions() *[]conditions.Condition {
+	return &o.Status.Conditions
+}
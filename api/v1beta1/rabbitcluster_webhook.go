@@ -0,0 +1,81 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var rabbitClusterLog = logf.Log.WithName("webhooks").WithName("rabbitcluster")
+
+// +kubebuilder:webhook:path=/mutate-rabbitmq-coderanger-net-v1beta1-rabbitcluster,mutating=true,failurePolicy=fail,sideEffects=None,groups=rabbitmq.coderanger.net,resources=rabbitclusters,verbs=create;update,versions=v1beta1,name=mrabbitcluster.kb.io,admissionReviewVersions=v1beta1
+
+var _ webhook.Defaulter = &RabbitCluster{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (obj *RabbitCluster) Default() {
+	rabbitClusterLog.Info("default", "name", obj.Name)
+
+	if obj.Spec.Protocol == "" {
+		obj.Spec.Protocol = "http"
+	}
+}
+
+// +kubebuilder:webhook:path=/validate-rabbitmq-coderanger-net-v1beta1-rabbitcluster,mutating=true,failurePolicy=fail,sideEffects=None,groups=rabbitmq.coderanger.net,resources=rabbitclusters,verbs=create;update,versions=v1beta1,name=vrabbitcluster.kb.io,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &RabbitCluster{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (obj *RabbitCluster) ValidateCreate() error {
+	rabbitClusterLog.Info("validate create", "name", obj.Name)
+	return obj.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (obj *RabbitCluster) ValidateUpdate(old runtime.Object) error {
+	rabbitClusterLog.Info("validate update", "name", obj.Name)
+	return obj.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type. Not used, just here for interface compliance.
+func (obj *RabbitCluster) ValidateDelete() error {
+	return nil
+}
+
+func (obj *RabbitCluster) validate() error {
+	if obj.Spec.Protocol != "http" && obj.Spec.Protocol != "https" {
+		return errors.Errorf("protocol %s is not http or https", obj.Spec.Protocol)
+	}
+	if obj.Spec.Host == "" {
+		return errors.New("host is required")
+	}
+	if obj.Spec.Username == "" {
+		return errors.New("username is required")
+	}
+	// Cluster-scoped objects have no namespace to default secrets to.
+	if obj.Spec.PasswordSecretRef != nil && obj.Spec.PasswordSecretRef.Namespace == "" {
+		return errors.New("passwordSecretRef namespace is required")
+	}
+	if obj.Spec.TLS != nil && obj.Spec.TLS.CASecretRef != nil && obj.Spec.TLS.CASecretRef.Namespace == "" {
+		return errors.New("tls caSecretRef namespace is required")
+	}
+	return nil
+}
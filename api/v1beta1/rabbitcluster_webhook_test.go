@@ -0,0 +1,87 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("RabbitCluster Webhook", func() {
+	var obj *RabbitCluster
+
+	BeforeEach(func() {
+		obj = &RabbitCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing"},
+			Spec: RabbitClusterSpec{
+				Protocol:          "https",
+				Host:              "rabbitmq.example.com",
+				Username:          "admin",
+				PasswordSecretRef: &ClusterSecretRef{Name: "rabbitmq-admin", Namespace: "rabbitmq"},
+			},
+		}
+	})
+
+	Describe("Default", func() {
+		It("sets the protocol if unset", func() {
+			obj.Spec.Protocol = ""
+			obj.Default()
+			Expect(obj.Spec.Protocol).To(Equal("http"))
+		})
+
+		It("does not set the protocol if set", func() {
+			obj.Default()
+			Expect(obj.Spec.Protocol).To(Equal("https"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts a simple object", func() {
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+			err = obj.ValidateUpdate(obj)
+			Expect(err).ToNot(HaveOccurred())
+			err = obj.ValidateDelete()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects an unknown protocol", func() {
+			obj.Spec.Protocol = "amqp"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("protocol amqp is not http or https"))
+		})
+
+		It("rejects a missing host", func() {
+			obj.Spec.Host = ""
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("host is required"))
+		})
+
+		It("rejects a password secret without a namespace", func() {
+			obj.Spec.PasswordSecretRef.Namespace = ""
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("passwordSecretRef namespace is required"))
+		})
+
+		It("rejects a CA secret without a namespace", func() {
+			obj.Spec.TLS = &RabbitClusterTLS{CASecretRef: &ClusterSecretRef{Name: "rabbitmq-ca"}}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("tls caSecretRef namespace is required"))
+		})
+	})
+})
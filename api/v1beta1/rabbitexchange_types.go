@@ -0,0 +1,79 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/coderanger/controller-utils/conditions"
+)
+
+// RabbitExchangeSpec defines the desired state of RabbitExchange
+type RabbitExchangeSpec struct {
+	ExchangeName string `json:"exchangeName,omitempty"`
+	Vhost        string `json:"vhost"`
+	// Exchange type: direct, fanout, topic, or headers. Defaults to direct.
+	Type       string `json:"type,omitempty"`
+	Durable    *bool  `json:"durable,omitempty"`
+	AutoDelete *bool  `json:"autoDelete,omitempty"`
+	Internal   *bool  `json:"internal,omitempty"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Arguments  *runtime.RawExtension `json:"arguments,omitempty"`
+	Connection RabbitConnection      `json:"connection,omitempty"`
+}
+
+// RabbitExchangeStatus defines the observed state of RabbitExchange
+type RabbitExchangeStatus struct {
+	// Represents the observations of a RabbitExchange's current state.
+	// Known .status.conditions.type are: Ready, ExchangeReady
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []conditions.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// RabbitExchange is the Schema for the rabbitexchanges API
+type RabbitExchange struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RabbitExchangeSpec   `json:"spec,omitempty"`
+	Status RabbitExchangeStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RabbitExchangeList contains a list of RabbitExchange
+type RabbitExchangeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RabbitExchange `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RabbitExchange{}, &RabbitExchangeList{})
+}
+
+// TODO code generator for this.
+func (o *RabbitExchange) GetConditions() *[]conditions.Condition {
+	return &o.Status.Conditions
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var rabbitExchangeLog = logf.Log.WithName("webhooks").WithName("rabbitexchange")
+
+// +kubebuilder:webhook:path=/mutate-rabbitmq-coderanger-net-v1beta1-rabbitexchange,mutating=true,failurePolicy=fail,sideEffects=None,groups=rabbitmq.coderanger.net,resources=rabbitexchanges,verbs=create;update,versions=v1beta1,name=mrabbitexchange.kb.io,admissionReviewVersions=v1beta1
+
+var _ webhook.Defaulter = &RabbitExchange{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (obj *RabbitExchange) Default() {
+	rabbitExchangeLog.Info("default", "name", obj.Name, "namespace", obj.Namespace)
+
+	if obj.Spec.ExchangeName == "" {
+		obj.Spec.ExchangeName = obj.Name
+	}
+	if obj.Spec.Type == "" {
+		obj.Spec.Type = "direct"
+	}
+}
+
+// +kubebuilder:webhook:path=/validate-rabbitmq-coderanger-net-v1beta1-rabbitexchange,mutating=true,failurePolicy=fail,sideEffects=None,groups=rabbitmq.coderanger.net,resources=rabbitexchanges,verbs=create;update,versions=v1beta1,name=vrabbitexchange.kb.io,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &RabbitExchange{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (obj *RabbitExchange) ValidateCreate() error {
+	rabbitExchangeLog.Info("validate create", "name", obj.Name, "namespace", obj.Namespace)
+	return obj.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (obj *RabbitExchange) ValidateUpdate(old runtime.Object) error {
+	rabbitExchangeLog.Info("validate update", "name", obj.Name, "namespace", obj.Namespace)
+	return obj.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type. Not used, just here for interface compliance.
+func (obj *RabbitExchange) ValidateDelete() error {
+	return nil
+}
+
+func (obj *RabbitExchange) validate() error {
+	err := ValidateConnection(&obj.Spec.Connection)
+	if err != nil {
+		return err
+	}
+
+	switch obj.Spec.Type {
+	case "direct", "fanout", "topic", "headers":
+	default:
+		return errors.Errorf("exchange type %s is not a known type", obj.Spec.Type)
+	}
+	return validateArguments(obj.Spec.Arguments)
+}
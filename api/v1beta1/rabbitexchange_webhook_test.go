@@ -0,0 +1,96 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var _ = Describe("RabbitExchange Webhook", func() {
+	var obj *RabbitExchange
+
+	BeforeEach(func() {
+		obj = &RabbitExchange{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing", Namespace: "default"},
+			Spec: RabbitExchangeSpec{
+				Vhost: "/",
+				Type:  "topic",
+			},
+		}
+	})
+
+	Describe("Default", func() {
+		It("sets the name if unset", func() {
+			obj.Default()
+			Expect(obj.Spec.ExchangeName).To(Equal("testing"))
+		})
+
+		It("does not set the name if set", func() {
+			obj.Spec.ExchangeName = "other"
+			obj.Default()
+			Expect(obj.Spec.ExchangeName).To(Equal("other"))
+		})
+
+		It("sets the type if unset", func() {
+			obj.Spec.Type = ""
+			obj.Default()
+			Expect(obj.Spec.Type).To(Equal("direct"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts a simple object", func() {
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+			err = obj.ValidateUpdate(obj)
+			Expect(err).ToNot(HaveOccurred())
+			err = obj.ValidateDelete()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects an unknown type", func() {
+			obj.Spec.Type = "x-consistent-hash"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("exchange type x-consistent-hash is not a known type"))
+		})
+
+		It("rejects malformed arguments", func() {
+			obj.Spec.Arguments = &runtime.RawExtension{
+				Raw: []byte(`{"alternate-exchange": []}`),
+			}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("argument alternate-exchange has an invalid value: []"))
+		})
+
+		It("rejects a host with a clusterRef", func() {
+			obj.Spec.Connection.ClusterRef = &RabbitClusterRef{Name: "other"}
+			obj.Spec.Connection.Host = "otherhost"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("connection protocol, host, port, and insecureSkipVerify can't be set with clusterRef"))
+		})
+
+		It("accepts a username with a clusterRef", func() {
+			obj.Spec.Connection.ClusterRef = &RabbitClusterRef{Name: "other"}
+			obj.Spec.Connection.Username = "someone"
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+})
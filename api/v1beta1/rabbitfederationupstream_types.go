@@ -0,0 +1,86 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/coderanger/controller-utils/conditions"
+)
+
+// RabbitFederationUpstreamSpec defines the desired state of RabbitFederationUpstream
+type RabbitFederationUpstreamSpec struct {
+	UpstreamName string `json:"upstreamName,omitempty"`
+	Vhost        string `json:"vhost"`
+	// Secret containing the AMQP URI of the upstream server. Key defaults to RABBIT_URL so a RabbitUser secret can be used directly.
+	URISecretRef SecretRef `json:"uriSecretRef"`
+	// Expiry time in milliseconds for the upstream queue.
+	Expires *int `json:"expires,omitempty"`
+	// Message TTL in milliseconds for the upstream queue.
+	MessageTTL     *int `json:"messageTTL,omitempty"`
+	MaxHops        *int `json:"maxHops,omitempty"`
+	PrefetchCount  *int `json:"prefetchCount,omitempty"`
+	ReconnectDelay *int `json:"reconnectDelay,omitempty"`
+	// Acknowledgement mode: on-confirm, on-publish, or no-ack. Defaults to on-confirm.
+	AckMode     string           `json:"ackMode,omitempty"`
+	TrustUserID *bool            `json:"trustUserId,omitempty"`
+	Connection  RabbitConnection `json:"connection,omitempty"`
+}
+
+// RabbitFederationUpstreamStatus defines the observed state of RabbitFederationUpstream
+type RabbitFederationUpstreamStatus struct {
+	// Represents the observations of a RabbitFederationUpstream's current state.
+	// Known .status.conditions.type are: Ready, FederationUpstreamReady, FederationLinksReady
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []conditions.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Vhost",type=string,JSONPath=".spec.vhost"
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Links",type=string,JSONPath=".status.conditions[?(@.type==\"FederationLinksReady\")].reason"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// RabbitFederationUpstream is the Schema for the rabbitfederationupstreams API
+type RabbitFederationUpstream struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RabbitFederationUpstreamSpec   `json:"spec,omitempty"`
+	Status RabbitFederationUpstreamStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RabbitFederationUpstreamList contains a list of RabbitFederationUpstream
+type RabbitFederationUpstreamList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RabbitFederationUpstream `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RabbitFederationUpstream{}, &RabbitFederationUpstreamList{})
+}
+
+// TODO code generator for this.
+func (o *RabbitFederationUpstream) GetConditions() *[]conditions.Condition {
+	return &o.Status.Conditions
+}
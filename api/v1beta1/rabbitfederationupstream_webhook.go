@@ -0,0 +1,94 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var rabbitFederationUpstreamLog = logf.Log.WithName("webhooks").WithName("rabbitfederationupstream")
+
+// +kubebuilder:webhook:path=/mutate-rabbitmq-coderanger-net-v1beta1-rabbitfederationupstream,mutating=true,failurePolicy=fail,sideEffects=None,groups=rabbitmq.coderanger.net,resources=rabbitfederationupstreams,verbs=create;update,versions=v1beta1,name=mrabbitfederationupstream.kb.io,admissionReviewVersions=v1beta1
+
+var _ webhook.Defaulter = &RabbitFederationUpstream{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (obj *RabbitFederationUpstream) Default() {
+	rabbitFederationUpstreamLog.Info("default", "name", obj.Name, "namespace", obj.Namespace)
+
+	if obj.Spec.UpstreamName == "" {
+		obj.Spec.UpstreamName = obj.Name
+	}
+	if obj.Spec.AckMode == "" {
+		obj.Spec.AckMode = "on-confirm"
+	}
+}
+
+// +kubebuilder:webhook:path=/validate-rabbitmq-coderanger-net-v1beta1-rabbitfederationupstream,mutating=true,failurePolicy=fail,sideEffects=None,groups=rabbitmq.coderanger.net,resources=rabbitfederationupstreams,verbs=create;update,versions=v1beta1,name=vrabbitfederationupstream.kb.io,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &RabbitFederationUpstream{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (obj *RabbitFederationUpstream) ValidateCreate() error {
+	rabbitFederationUpstreamLog.Info("validate create", "name", obj.Name, "namespace", obj.Namespace)
+	return obj.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (obj *RabbitFederationUpstream) ValidateUpdate(old runtime.Object) error {
+	rabbitFederationUpstreamLog.Info("validate update", "name", obj.Name, "namespace", obj.Namespace)
+	return obj.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type. Not used, just here for interface compliance.
+func (obj *RabbitFederationUpstream) ValidateDelete() error {
+	return nil
+}
+
+func (obj *RabbitFederationUpstream) validate() error {
+	err := ValidateConnection(&obj.Spec.Connection)
+	if err != nil {
+		return err
+	}
+
+	switch obj.Spec.AckMode {
+	case "on-confirm", "on-publish", "no-ack":
+	default:
+		return errors.Errorf("ack mode %s is not a known mode", obj.Spec.AckMode)
+	}
+	if obj.Spec.URISecretRef.Name == "" {
+		return errors.New("uriSecretRef is required")
+	}
+	if obj.Spec.MaxHops != nil && *obj.Spec.MaxHops < 1 {
+		return errors.New("maxHops must be at least 1")
+	}
+	positive := map[string]*int{
+		"expires":        obj.Spec.Expires,
+		"messageTTL":     obj.Spec.MessageTTL,
+		"prefetchCount":  obj.Spec.PrefetchCount,
+		"reconnectDelay": obj.Spec.ReconnectDelay,
+	}
+	for field, value := range positive {
+		if value != nil && *value < 0 {
+			return errors.Errorf("%s must not be negative", field)
+		}
+	}
+	return nil
+}
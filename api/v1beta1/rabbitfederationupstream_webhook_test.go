@@ -0,0 +1,81 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("RabbitFederationUpstream Webhook", func() {
+	var obj *RabbitFederationUpstream
+
+	BeforeEach(func() {
+		obj = &RabbitFederationUpstream{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing", Namespace: "default"},
+			Spec: RabbitFederationUpstreamSpec{
+				Vhost:        "/",
+				URISecretRef: SecretRef{Name: "upstream"},
+				AckMode:      "on-confirm",
+			},
+		}
+	})
+
+	Describe("Default", func() {
+		It("sets the name if unset", func() {
+			obj.Default()
+			Expect(obj.Spec.UpstreamName).To(Equal("testing"))
+		})
+
+		It("sets the ack mode if unset", func() {
+			obj.Spec.AckMode = ""
+			obj.Default()
+			Expect(obj.Spec.AckMode).To(Equal("on-confirm"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts a simple object", func() {
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+			err = obj.ValidateUpdate(obj)
+			Expect(err).ToNot(HaveOccurred())
+			err = obj.ValidateDelete()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects an unknown ack mode", func() {
+			obj.Spec.AckMode = "sometimes"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("ack mode sometimes is not a known mode"))
+		})
+
+		It("rejects zero max hops", func() {
+			maxHops := 0
+			obj.Spec.MaxHops = &maxHops
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("maxHops must be at least 1"))
+		})
+
+		It("rejects a negative message TTL", func() {
+			ttl := -1
+			obj.Spec.MessageTTL = &ttl
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("messageTTL must not be negative"))
+		})
+	})
+})
@@ -17,36 +17,165 @@ limitations under the License.
 package v1beta1
 
 import (
+	"bytes"
+	"text/template"
+
+	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/coderanger/controller-utils/conditions"
 )
 
+// Settings specific to quorum queues.
+type RabbitQuorumQueue struct {
+	// Number of replicas to create the queue with, defaults to the size of the cluster.
+	InitialGroupSize *int `json:"initialGroupSize,omitempty"`
+	// Number of redeliveries before a message is dropped or dead-lettered.
+	DeliveryLimit *int `json:"deliveryLimit,omitempty"`
+	// Number of replicas to keep, growing onto new nodes and shrinking off removed ones. Capped at the number of
+	// running nodes. Unset leaves membership alone after creation.
+	TargetMembers *int `json:"targetMembers,omitempty"`
+}
+
+// Settings specific to streams.
+type RabbitStreamQueue struct {
+	// Retention period for stream data, e.g. 7D or 12h. Units are Y, M, D, h, m, and s.
+	MaxAge         string `json:"maxAge,omitempty"`
+	MaxSegmentSize *int64 `json:"maxSegmentSize,omitempty"`
+}
+
+// Dead-letter exchange and queue managed along with the main queue.
+type RabbitDeadLetter struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Name of the dead-letter exchange, defaults to <queueName>.dlx.
+	ExchangeName string `json:"exchangeName,omitempty"`
+	// Name of the dead-letter queue, defaults to <queueName>.dlq.
+	QueueName string `json:"queueName,omitempty"`
+	// Routing key dead-lettered messages are published and bound with, defaults to the queue name.
+	RoutingKey string `json:"routingKey,omitempty"`
+	// How long in milliseconds messages are kept in the dead-letter queue. Unset keeps them until consumed.
+	TTL *int `json:"ttl,omitempty"`
+	// Number of redeliveries before a message is dead-lettered, using x-delivery-limit so only quorum queues
+	// support it.
+	MaxRetries *int `json:"maxRetries,omitempty"`
+}
+
 // RabbitUserSpec defines the desired state of RabbitUser
 type RabbitQueueSpec struct {
-	QueueName  string `json:"queueName,omitempty"`
-	Vhost      string `json:"vhost"`
-	AutoDelete *bool  `json:"autoDelete,omitempty"`
-	Durable    *bool  `json:"durable,omitempty"`
+	QueueName string `json:"queueName,omitempty"`
+	// Go template for the queue name when queueName isn't set, e.g. {{ .Namespace }}.{{ .Name }}. It can use
+	// .Namespace, .Name, and .Labels. Defaults to the operator's --queue-name-template, or the object name. The
+	// rendered name is recorded in the status once the queue exists and later template changes don't rename it.
+	QueueNameTemplate string `json:"queueNameTemplate,omitempty"`
+	Vhost             string `json:"vhost"`
+	// Queue type: classic, quorum, or stream. Unset leaves it to the vhost default queue type, usually classic.
+	// +kubebuilder:validation:Enum=classic;quorum;stream
+	Type       string             `json:"type,omitempty"`
+	Quorum     *RabbitQuorumQueue `json:"quorum,omitempty"`
+	Stream     *RabbitStreamQueue `json:"stream,omitempty"`
+	DeadLetter *RabbitDeadLetter  `json:"deadLetter,omitempty"`
+	AutoDelete *bool              `json:"autoDelete,omitempty"`
+	Durable    *bool              `json:"durable,omitempty"`
 	// +kubebuilder:pruning:PreserveUnknownFields
-	Arguments  *runtime.RawExtension `json:"arguments,omitempty"`
-	Connection RabbitConnection      `json:"connection,omitempty"`
+	Arguments *runtime.RawExtension `json:"arguments,omitempty"`
+	// How to compare arguments on an existing queue. Subset (the default) only checks the arguments in the spec, Exact
+	// also treats any extra arguments on the queue as drift.
+	// +kubebuilder:validation:Enum=Subset;Exact
+	ArgumentsMode string `json:"argumentsMode,omitempty"`
+	// What to do when the settings of an existing queue don't match. IfEmpty (the default) recreates the queue only
+	// once it is empty, Shovel moves the messages through a temporary queue, and Never leaves the queue alone.
+	// +kubebuilder:validation:Enum=Shovel;IfEmpty;Never
+	MigrationPolicy string `json:"migrationPolicy,omitempty"`
+	// Manage a queue that already exists on the broker rather than refusing to. The
+	// rabbitmq.coderanger.net/adopt annotation does the same.
+	Adopt          bool           `json:"adopt,omitempty"`
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// How often to refresh the queue statistics in the status. Defaults to 1m, 0 records them once and never refreshes.
+	StatsInterval *metav1.Duration `json:"statsInterval,omitempty"`
+	Connection    RabbitConnection `json:"connection,omitempty"`
+}
+
+// Progress of a Shovel migration.
+type RabbitQueueMigration struct {
+	// Current phase: MovingToTemporary, Redeclaring, or MovingBack.
+	Phase          string      `json:"phase"`
+	TemporaryQueue string      `json:"temporaryQueue"`
+	StartTime      metav1.Time `json:"startTime,omitempty"`
+	// Bindings copied from the queue, moved to the temporary queue and back so messages keep being routed.
+	Bindings []RabbitQueueMigrationBinding `json:"bindings,omitempty"`
+}
+
+// A binding to the queue carried across a migration.
+type RabbitQueueMigrationBinding struct {
+	Source     string `json:"source"`
+	RoutingKey string `json:"routingKey,omitempty"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Arguments *runtime.RawExtension `json:"arguments,omitempty"`
+}
+
+// Record of the last purge requested with the rabbitmq.coderanger.net/purge annotation.
+type RabbitQueuePurge struct {
+	// Annotation value the purge was done for, each new value purges the queue again.
+	Nonce string `json:"nonce"`
+	// Approximate number of messages removed, as reported just before purging.
+	Messages int         `json:"messages"`
+	Time     metav1.Time `json:"time"`
+}
+
+// Point-in-time statistics for a queue from the management API.
+type RabbitQueueStats struct {
+	MessagesReady          int `json:"messagesReady"`
+	MessagesUnacknowledged int `json:"messagesUnacknowledged"`
+	Consumers              int `json:"consumers"`
+	// Bytes of memory used by the queue process.
+	Memory int64  `json:"memory"`
+	State  string `json:"state,omitempty"`
+	// Node hosting the queue, or the current leader for quorum queues and streams.
+	Node string `json:"node,omitempty"`
+	// Name of the policy applied to the queue, if any.
+	Policy      string      `json:"policy,omitempty"`
+	LastUpdated metav1.Time `json:"lastUpdated,omitempty"`
 }
 
 // RabbitQueueStatus defines the observed state of RabbitQueue
 type RabbitQueueStatus struct {
 	// Represents the observations of a RabbitQueues's current state.
-	// Known .status.conditions.type are: Ready, QueueReady
+	// Known .status.conditions.type are: Ready, QueueReady, QueueDrifted, DeadLetterDrifted, MembersReady
 	// +patchMergeKey=type
 	// +patchStrategy=merge
 	// +listType=map
 	// +listMapKey=type
 	Conditions []conditions.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+	// Set once this object has created or adopted the queue, only then is it deleted by the finalizer.
+	Owned bool `json:"owned,omitempty"`
+	// Set while this object hasn't created or adopted the queue yet, or the queue exists on the broker but this object
+	// isn't allowed to manage it.
+	OwnershipConflict bool `json:"ownershipConflict,omitempty"`
+	// Name of the queue on the broker, fixed once the queue has been created or adopted.
+	QueueName string `json:"queueName,omitempty"`
+	// Set while a Shovel migration is in progress.
+	Migration *RabbitQueueMigration `json:"migration,omitempty"`
+	// Statistics as of the last refresh.
+	Stats *RabbitQueueStats `json:"stats,omitempty"`
+	// Nodes currently hosting replicas of a quorum queue with targetMembers set.
+	Members []string `json:"members,omitempty"`
+	// The most recent purge, if any.
+	LastPurge *RabbitQueuePurge `json:"lastPurge,omitempty"`
+	// Set once this object has created the dead-letter exchange, only then is it deleted by the finalizer.
+	DeadLetterExchangeCreated bool `json:"deadLetterExchangeCreated,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Vhost",type=string,JSONPath=".spec.vhost"
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=".spec.type"
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Messages",type=integer,JSONPath=".status.stats.messagesReady"
+// +kubebuilder:printcolumn:name="Unacked",type=integer,JSONPath=".status.stats.messagesUnacknowledged"
+// +kubebuilder:printcolumn:name="Consumers",type=integer,JSONPath=".status.stats.consumers"
+// +kubebuilder:printcolumn:name="Migration",type=string,JSONPath=".status.migration.phase"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
 
 // RabbitQueue is the Schema for the rabbitQueues API
 type RabbitQueue struct {
@@ -74,3 +203,51 @@ func init() {
 func (o *RabbitQueue) GetConditions() *[]conditions.Condition {
 	return &o.Status.Conditions
 }
+
+// Operator-wide template for queue names, used when neither queueName nor queueNameTemplate is set.
+var DefaultQueueNameTemplate string
+
+type queueNameTemplateData struct {
+	Namespace string
+	Name      string
+	Labels    map[string]string
+}
+
+// ResolveQueueName returns the name of the queue on the broker. An explicit queueName always wins, otherwise the
+// name recorded in the status is used so the queue doesn't move when the template changes.
+func (o *RabbitQueue) ResolveQueueName() (string, error) {
+	if o.Spec.QueueName != "" {
+		return o.Spec.QueueName, nil
+	}
+	if o.Status.QueueName != "" {
+		return o.Status.QueueName, nil
+	}
+	return o.renderQueueName()
+}
+
+func (o *RabbitQueue) queueNameTemplate() string {
+	if o.Spec.QueueNameTemplate != "" {
+		return o.Spec.QueueNameTemplate
+	}
+	return DefaultQueueNameTemplate
+}
+
+func (o *RabbitQueue) renderQueueName() (string, error) {
+	text := o.queueNameTemplate()
+	if text == "" {
+		return o.Name, nil
+	}
+	tmpl, err := template.New("queueName").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", errors.Wrap(err, "error parsing queue name template")
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, queueNameTemplateData{Namespace: o.Namespace, Name: o.Name, Labels: o.Labels})
+	if err != nil {
+		return "", errors.Wrap(err, "error rendering queue name template")
+	}
+	if buf.Len() == 0 {
+		return "", errors.New("queue name template rendered an empty name")
+	}
+	return buf.String(), nil
+}
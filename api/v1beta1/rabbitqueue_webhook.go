@@ -18,6 +18,7 @@ package v1beta1
 
 import (
 	"encoding/json"
+	"regexp"
 
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -36,9 +37,36 @@ var _ webhook.Defaulter = &RabbitQueue{}
 func (obj *RabbitQueue) Default() {
 	rabbitQueueLog.Info("default", "name", obj.Name, "namespace", obj.Namespace)
 
-	if obj.Spec.QueueName == "" {
+	if obj.Spec.QueueName == "" && obj.queueNameTemplate() == "" {
 		obj.Spec.QueueName = obj.Name
 	}
+	if obj.Spec.DeletionPolicy == "" {
+		obj.Spec.DeletionPolicy = "Delete"
+	}
+	if obj.Spec.MigrationPolicy == "" {
+		obj.Spec.MigrationPolicy = "IfEmpty"
+	}
+	if obj.Spec.ArgumentsMode == "" {
+		obj.Spec.ArgumentsMode = "Subset"
+	}
+	// A broken template is reported by validation, so leave the dead-letter names alone until then.
+	queue, err := obj.ResolveQueueName()
+	if err == nil && obj.Spec.DeadLetter != nil && obj.Spec.DeadLetter.Enabled {
+		if obj.Spec.DeadLetter.ExchangeName == "" {
+			obj.Spec.DeadLetter.ExchangeName = queue + ".dlx"
+		}
+		if obj.Spec.DeadLetter.QueueName == "" {
+			obj.Spec.DeadLetter.QueueName = queue + ".dlq"
+		}
+		if obj.Spec.DeadLetter.RoutingKey == "" {
+			obj.Spec.DeadLetter.RoutingKey = queue
+		}
+	}
+	// Quorum queues and streams are always durable.
+	if (obj.Spec.Type == "quorum" || obj.Spec.Type == "stream") && obj.Spec.Durable == nil {
+		durable := true
+		obj.Spec.Durable = &durable
+	}
 }
 
 // +kubebuilder:webhook:path=/validate-rabbitmq-coderanger-net-v1beta1-rabbitqueue,mutating=true,failurePolicy=fail,sideEffects=None,groups=rabbitmq.coderanger.net,resources=rabbitqueues,verbs=create;update,versions=v1beta1,name=vrabbitqueue.kb.io,admissionReviewVersions=v1beta1
@@ -63,10 +91,149 @@ func (obj *RabbitQueue) ValidateDelete() error {
 }
 
 func (obj *RabbitQueue) validate() error {
+	err := ValidateConnection(&obj.Spec.Connection)
+	if err != nil {
+		return err
+	}
+
+	switch obj.Spec.Type {
+	case "", "classic":
+	case "quorum", "stream":
+		if obj.Spec.Durable != nil && !*obj.Spec.Durable {
+			return errors.Errorf("%s queues must be durable", obj.Spec.Type)
+		}
+		if obj.Spec.AutoDelete != nil && *obj.Spec.AutoDelete {
+			return errors.Errorf("%s queues cannot be autoDelete", obj.Spec.Type)
+		}
+	default:
+		return errors.Errorf("queue type %s is not a known queue type", obj.Spec.Type)
+	}
+
+	if obj.Spec.QueueName != "" && obj.Spec.QueueNameTemplate != "" {
+		return errors.New("queueName and queueNameTemplate are mutually exclusive")
+	}
+	// Check the template even once the name is frozen, so a broken edit doesn't go unnoticed.
+	if obj.Spec.QueueName == "" {
+		_, err := obj.renderQueueName()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = validateDeletionPolicy(obj.Spec.DeletionPolicy)
+	if err != nil {
+		return err
+	}
+
+	switch obj.Spec.MigrationPolicy {
+	case "Shovel", "IfEmpty", "Never":
+	default:
+		return errors.Errorf("migration policy %s is not a known policy", obj.Spec.MigrationPolicy)
+	}
+
+	switch obj.Spec.ArgumentsMode {
+	case "Subset", "Exact":
+	default:
+		return errors.Errorf("arguments mode %s is not a known mode", obj.Spec.ArgumentsMode)
+	}
+
+	if obj.Spec.Quorum != nil {
+		if obj.Spec.Type != "quorum" {
+			return errors.New("quorum settings require type quorum")
+		}
+		if obj.Spec.Quorum.InitialGroupSize != nil && *obj.Spec.Quorum.InitialGroupSize < 1 {
+			return errors.New("quorum initialGroupSize must be at least 1")
+		}
+		if obj.Spec.Quorum.DeliveryLimit != nil && *obj.Spec.Quorum.DeliveryLimit < 0 {
+			return errors.New("quorum deliveryLimit must not be negative")
+		}
+		if obj.Spec.Quorum.TargetMembers != nil && *obj.Spec.Quorum.TargetMembers < 1 {
+			return errors.New("quorum targetMembers must be at least 1")
+		}
+	}
+
+	if obj.Spec.Stream != nil {
+		if obj.Spec.Type != "stream" {
+			return errors.New("stream settings require type stream")
+		}
+		if obj.Spec.Stream.MaxAge != "" && !maxAgeRegexp.MatchString(obj.Spec.Stream.MaxAge) {
+			return errors.Errorf("stream maxAge %s is not a valid age", obj.Spec.Stream.MaxAge)
+		}
+		if obj.Spec.Stream.MaxSegmentSize != nil && *obj.Spec.Stream.MaxSegmentSize < 1 {
+			return errors.New("stream maxSegmentSize must be positive")
+		}
+	}
+
+	if obj.Spec.DeadLetter != nil && obj.Spec.DeadLetter.Enabled {
+		err = obj.validateDeadLetter()
+		if err != nil {
+			return err
+		}
+	}
+
+	if obj.Spec.StatsInterval != nil && obj.Spec.StatsInterval.Duration < 0 {
+		return errors.Errorf("statsInterval %s must not be negative", obj.Spec.StatsInterval.Duration)
+	}
+
+	err = validateArguments(obj.Spec.Arguments)
+	if err != nil {
+		return err
+	}
+
+	// Don't let the untyped arguments disagree with the typed field.
+	if obj.Spec.Type != "" && obj.Spec.Arguments != nil {
+		var args map[string]interface{}
+		_ = json.Unmarshal(obj.Spec.Arguments.Raw, &args)
+		argType, ok := args["x-queue-type"]
+		if ok && argType != obj.Spec.Type {
+			return errors.Errorf("argument x-queue-type %v conflicts with type %s", argType, obj.Spec.Type)
+		}
+	}
+	return nil
+}
+
+func (obj *RabbitQueue) validateDeadLetter() error {
+	deadLetter := obj.Spec.DeadLetter
+	queue, _ := obj.ResolveQueueName()
+	if deadLetter.QueueName == queue {
+		return errors.New("deadLetter queueName must be different from the queue")
+	}
+	if deadLetter.TTL != nil && *deadLetter.TTL < 0 {
+		return errors.New("deadLetter ttl must not be negative")
+	}
+	if deadLetter.MaxRetries != nil {
+		if obj.Spec.Type != "quorum" {
+			return errors.New("deadLetter maxRetries requires type quorum")
+		}
+		if *deadLetter.MaxRetries < 0 {
+			return errors.New("deadLetter maxRetries must not be negative")
+		}
+		if obj.Spec.Quorum != nil && obj.Spec.Quorum.DeliveryLimit != nil {
+			return errors.New("deadLetter maxRetries conflicts with quorum deliveryLimit")
+		}
+	}
+	// The generated arguments would silently win, so don't allow setting them by hand too.
 	if obj.Spec.Arguments != nil {
+		var args map[string]interface{}
+		_ = json.Unmarshal(obj.Spec.Arguments.Raw, &args)
+		for _, key := range []string{"x-dead-letter-exchange", "x-dead-letter-routing-key"} {
+			_, ok := args[key]
+			if ok {
+				return errors.Errorf("argument %s conflicts with deadLetter", key)
+			}
+		}
+	}
+	return nil
+}
+
+var maxAgeRegexp = regexp.MustCompile(`^[0-9]+[YMDhms]$`)
+
+// Shared validation for queue and exchange arguments.
+func validateArguments(arguments *runtime.RawExtension) error {
+	if arguments != nil {
 		// Validate arguments.
 		var args map[string]interface{}
-		err := json.Unmarshal(obj.Spec.Arguments.Raw, &args)
+		err := json.Unmarshal(arguments.Raw, &args)
 		if err != nil {
 			return errors.Wrap(err, "error parsing arguments")
 		}
@@ -84,3 +251,22 @@ func (obj *RabbitQueue) validate() error {
 	}
 	return nil
 }
+
+// Shared validation for queue, vhost, and user deletion policies.
+func validateDeletionPolicy(policy DeletionPolicy) error {
+	switch policy {
+	case "Delete", "Retain", "DeleteIfEmpty":
+		return nil
+	default:
+		return errors.Errorf("deletion policy %s is not a known policy", policy)
+	}
+}
+
+// ValidateConnection checks a connection for every kind that has one, and again in the operator before connecting.
+// With a clusterRef the cluster's admin credentials are used, so the endpoint can't be pointed anywhere else.
+func ValidateConnection(connection *RabbitConnection) error {
+	if connection.ClusterRef != nil && (connection.Protocol != "" || connection.Host != "" || connection.Port != 0 || connection.InsecureSkipVerify != nil) {
+		return errors.New("connection protocol, host, port, and insecureSkipVerify can't be set with clusterRef")
+	}
+	return nil
+}
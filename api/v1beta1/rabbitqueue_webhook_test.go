@@ -0,0 +1,268 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var _ = Describe("RabbitQueue Webhook", func() {
+	var obj *RabbitQueue
+
+	BeforeEach(func() {
+		obj = &RabbitQueue{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing", Namespace: "default"},
+			Spec: RabbitQueueSpec{
+				Vhost:           "/",
+				MigrationPolicy: "IfEmpty",
+				DeletionPolicy:  "Delete",
+				ArgumentsMode:   "Subset",
+			},
+		}
+	})
+
+	Describe("Default", func() {
+		It("sets the name if unset", func() {
+			obj.Default()
+			Expect(obj.Spec.QueueName).To(Equal("testing"))
+		})
+
+		It("leaves the name unset with a template", func() {
+			obj.Spec.QueueNameTemplate = "{{ .Namespace }}.{{ .Name }}"
+			obj.Spec.DeadLetter = &RabbitDeadLetter{Enabled: true}
+			obj.Default()
+			Expect(obj.Spec.QueueName).To(Equal(""))
+			Expect(obj.Spec.DeadLetter.QueueName).To(Equal("default.testing.dlq"))
+		})
+
+		It("leaves the name unset with an operator template", func() {
+			DefaultQueueNameTemplate = "{{ .Labels.team }}-{{ .Name }}"
+			defer func() { DefaultQueueNameTemplate = "" }()
+			obj.Labels = map[string]string{"team": "payments"}
+			obj.Default()
+			Expect(obj.Spec.QueueName).To(Equal(""))
+			Expect(obj.ResolveQueueName()).To(Equal("payments-testing"))
+		})
+
+		It("sets the migration policy if unset", func() {
+			obj.Spec.MigrationPolicy = ""
+			obj.Default()
+			Expect(obj.Spec.MigrationPolicy).To(Equal("IfEmpty"))
+		})
+
+		It("sets the arguments mode if unset", func() {
+			obj.Spec.ArgumentsMode = ""
+			obj.Default()
+			Expect(obj.Spec.ArgumentsMode).To(Equal("Subset"))
+		})
+
+		It("sets dead-letter names if enabled", func() {
+			obj.Spec.DeadLetter = &RabbitDeadLetter{Enabled: true}
+			obj.Default()
+			Expect(obj.Spec.DeadLetter).To(PointTo(MatchFields(IgnoreExtras, Fields{
+				"ExchangeName": Equal("testing.dlx"),
+				"QueueName":    Equal("testing.dlq"),
+				"RoutingKey":   Equal("testing"),
+			})))
+		})
+
+		It("makes quorum queues durable", func() {
+			obj.Spec.Type = "quorum"
+			obj.Default()
+			Expect(obj.Spec.Durable).To(PointTo(BeTrue()))
+		})
+
+		It("leaves classic queues alone", func() {
+			obj.Default()
+			Expect(obj.Spec.Durable).To(BeNil())
+		})
+	})
+
+	Describe("ResolveQueueName", func() {
+		It("uses the object name by default", func() {
+			Expect(obj.ResolveQueueName()).To(Equal("testing"))
+		})
+
+		It("renders the template", func() {
+			obj.Spec.QueueNameTemplate = "{{ .Namespace }}.{{ .Name }}"
+			Expect(obj.ResolveQueueName()).To(Equal("default.testing"))
+		})
+
+		It("keeps the name from the status once set", func() {
+			obj.Spec.QueueNameTemplate = "{{ .Namespace }}.{{ .Name }}"
+			obj.Status.QueueName = "old.testing"
+			Expect(obj.ResolveQueueName()).To(Equal("old.testing"))
+		})
+
+		It("prefers an explicit queue name", func() {
+			obj.Spec.QueueName = "other"
+			obj.Status.QueueName = "old.testing"
+			Expect(obj.ResolveQueueName()).To(Equal("other"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts a simple object", func() {
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+			err = obj.ValidateUpdate(obj)
+			Expect(err).ToNot(HaveOccurred())
+			err = obj.ValidateDelete()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects an unknown deletion policy", func() {
+			obj.Spec.DeletionPolicy = "Archive"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("deletion policy Archive is not a known policy"))
+		})
+
+		It("rejects an unknown migration policy", func() {
+			obj.Spec.MigrationPolicy = "Always"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("migration policy Always is not a known policy"))
+		})
+
+		It("rejects an unknown arguments mode", func() {
+			obj.Spec.ArgumentsMode = "Superset"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("arguments mode Superset is not a known mode"))
+		})
+
+		It("accepts a quorum queue", func() {
+			groupSize := 3
+			obj.Spec.Type = "quorum"
+			obj.Spec.Quorum = &RabbitQuorumQueue{InitialGroupSize: &groupSize}
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects a non-durable quorum queue", func() {
+			durable := false
+			obj.Spec.Type = "quorum"
+			obj.Spec.Durable = &durable
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("quorum queues must be durable"))
+		})
+
+		It("rejects an autoDelete quorum queue", func() {
+			autoDelete := true
+			obj.Spec.Type = "quorum"
+			obj.Spec.AutoDelete = &autoDelete
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("quorum queues cannot be autoDelete"))
+		})
+
+		It("rejects a zero targetMembers", func() {
+			targetMembers := 0
+			obj.Spec.Type = "quorum"
+			obj.Spec.Quorum = &RabbitQuorumQueue{TargetMembers: &targetMembers}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("quorum targetMembers must be at least 1"))
+		})
+
+		It("rejects quorum settings on a classic queue", func() {
+			obj.Spec.Quorum = &RabbitQuorumQueue{}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("quorum settings require type quorum"))
+		})
+
+		It("accepts a stream", func() {
+			obj.Spec.Type = "stream"
+			obj.Spec.Stream = &RabbitStreamQueue{MaxAge: "7D"}
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects an invalid stream max age", func() {
+			obj.Spec.Type = "stream"
+			obj.Spec.Stream = &RabbitStreamQueue{MaxAge: "7 days"}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("stream maxAge 7 days is not a valid age"))
+		})
+
+		It("rejects a conflicting x-queue-type argument", func() {
+			obj.Spec.Type = "quorum"
+			obj.Spec.Arguments = &runtime.RawExtension{Raw: []byte(`{"x-queue-type": "classic"}`)}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("argument x-queue-type classic conflicts with type quorum"))
+		})
+
+		It("accepts dead-lettering with max retries on a quorum queue", func() {
+			maxRetries := 3
+			obj.Spec.Type = "quorum"
+			obj.Spec.DeadLetter = &RabbitDeadLetter{Enabled: true, MaxRetries: &maxRetries}
+			obj.Default()
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects max retries on a classic queue", func() {
+			maxRetries := 3
+			obj.Spec.DeadLetter = &RabbitDeadLetter{Enabled: true, MaxRetries: &maxRetries}
+			obj.Default()
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("deadLetter maxRetries requires type quorum"))
+		})
+
+		It("rejects a dead-letter argument alongside deadLetter", func() {
+			obj.Spec.DeadLetter = &RabbitDeadLetter{Enabled: true}
+			obj.Spec.Arguments = &runtime.RawExtension{Raw: []byte(`{"x-dead-letter-exchange": "other"}`)}
+			obj.Default()
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("argument x-dead-letter-exchange conflicts with deadLetter"))
+		})
+
+		It("rejects a dead-letter queue with the same name as the queue", func() {
+			obj.Spec.DeadLetter = &RabbitDeadLetter{Enabled: true, QueueName: "testing"}
+			obj.Default()
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("deadLetter queueName must be different from the queue"))
+		})
+
+		It("rejects a queue name and a template together", func() {
+			obj.Spec.QueueName = "testing"
+			obj.Spec.QueueNameTemplate = "{{ .Name }}"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("queueName and queueNameTemplate are mutually exclusive"))
+		})
+
+		It("rejects a template using a missing label", func() {
+			obj.Spec.QueueNameTemplate = "{{ .Labels.team }}"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("error rendering queue name template")))
+		})
+
+		It("rejects a template rendering an empty name", func() {
+			obj.Spec.QueueNameTemplate = "{{ if false }}x{{ end }}"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("queue name template rendered an empty name"))
+		})
+
+		It("rejects a negative stats interval", func() {
+			obj.Spec.StatsInterval = &metav1.Duration{Duration: -time.Minute}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("statsInterval -1m0s must not be negative"))
+		})
+	})
+})
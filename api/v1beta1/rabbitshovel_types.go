@@ -0,0 +1,98 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/coderanger/controller-utils/conditions"
+)
+
+// RabbitShovelEndpoint defines one end of a shovel.
+type RabbitShovelEndpoint struct {
+	// Connection to the RabbitMQ server for this end of the shovel. The username and passwordSecretRef are required and
+	// clusterRef can't be used. The host defaults to the operator-wide default connection.
+	Connection *RabbitConnection `json:"connection,omitempty"`
+	// Secret created by a RabbitUser to take the AMQP URI from instead of a connection. Key defaults to RABBIT_URL.
+	// One of connection and userSecretRef is required.
+	UserSecretRef *SecretRef `json:"userSecretRef,omitempty"`
+	// Vhost to connect to.
+	Vhost string `json:"vhost,omitempty"`
+	// Queue to consume from or publish to.
+	Queue string `json:"queue,omitempty"`
+	// Exchange to consume from or publish to, instead of a queue.
+	Exchange string `json:"exchange,omitempty"`
+	// Routing key to use with the exchange.
+	ExchangeKey string `json:"exchangeKey,omitempty"`
+}
+
+// RabbitShovelSpec defines the desired state of RabbitShovel
+type RabbitShovelSpec struct {
+	ShovelName string `json:"shovelName,omitempty"`
+	// Vhost the shovel runs in.
+	Vhost       string               `json:"vhost"`
+	Source      RabbitShovelEndpoint `json:"source"`
+	Destination RabbitShovelEndpoint `json:"destination"`
+	// Acknowledgement mode: on-confirm, on-publish, or no-ack. Defaults to on-confirm.
+	AckMode        string `json:"ackMode,omitempty"`
+	PrefetchCount  int    `json:"prefetchCount,omitempty"`
+	ReconnectDelay int    `json:"reconnectDelay,omitempty"`
+	// When to remove the shovel: never, queue-length, or a number of messages. Defaults to never.
+	DeleteAfter       string           `json:"deleteAfter,omitempty"`
+	AddForwardHeaders bool             `json:"addForwardHeaders,omitempty"`
+	Connection        RabbitConnection `json:"connection,omitempty"`
+}
+
+// RabbitShovelStatus defines the observed state of RabbitShovel
+type RabbitShovelStatus struct {
+	// Represents the observations of a RabbitShovel's current state.
+	// Known .status.conditions.type are: Ready, ShovelReady
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []conditions.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// RabbitShovel is the Schema for the rabbitshovels API
+type RabbitShovel struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RabbitShovelSpec   `json:"spec,omitempty"`
+	Status RabbitShovelStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RabbitShovelList contains a list of RabbitShovel
+type RabbitShovelList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RabbitShovel `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RabbitShovel{}, &RabbitShovelList{})
+}
+
+// TODO code generator for this.
+func (o *RabbitShovel) GetConditions() *[]conditions.Condition {
+	return &o.Status.Conditions
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var rabbitShovelLog = logf.Log.WithName("webhooks").WithName("rabbitshovel")
+
+// +kubebuilder:webhook:path=/mutate-rabbitmq-coderanger-net-v1beta1-rabbitshovel,mutating=true,failurePolicy=fail,sideEffects=None,groups=rabbitmq.coderanger.net,resources=rabbitshovels,verbs=create;update,versions=v1beta1,name=mrabbitshovel.kb.io,admissionReviewVersions=v1beta1
+
+var _ webhook.Defaulter = &RabbitShovel{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (obj *RabbitShovel) Default() {
+	rabbitShovelLog.Info("default", "name", obj.Name, "namespace", obj.Namespace)
+
+	if obj.Spec.ShovelName == "" {
+		obj.Spec.ShovelName = obj.Name
+	}
+	if obj.Spec.AckMode == "" {
+		obj.Spec.AckMode = "on-confirm"
+	}
+}
+
+// +kubebuilder:webhook:path=/validate-rabbitmq-coderanger-net-v1beta1-rabbitshovel,mutating=true,failurePolicy=fail,sideEffects=None,groups=rabbitmq.coderanger.net,resources=rabbitshovels,verbs=create;update,versions=v1beta1,name=vrabbitshovel.kb.io,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &RabbitShovel{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (obj *RabbitShovel) ValidateCreate() error {
+	rabbitShovelLog.Info("validate create", "name", obj.Name, "namespace", obj.Namespace)
+	return obj.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (obj *RabbitShovel) ValidateUpdate(old runtime.Object) error {
+	rabbitShovelLog.Info("validate update", "name", obj.Name, "namespace", obj.Namespace)
+	return obj.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type. Not used, just here for interface compliance.
+func (obj *RabbitShovel) ValidateDelete() error {
+	return nil
+}
+
+func (obj *RabbitShovel) validate() error {
+	err := ValidateConnection(&obj.Spec.Connection)
+	if err != nil {
+		return err
+	}
+
+	switch obj.Spec.AckMode {
+	case "on-confirm", "on-publish", "no-ack":
+	default:
+		return errors.Errorf("ack mode %s is not a known mode", obj.Spec.AckMode)
+	}
+	switch obj.Spec.DeleteAfter {
+	case "", "never", "queue-length":
+	default:
+		_, err := strconv.Atoi(obj.Spec.DeleteAfter)
+		if err != nil {
+			return errors.Errorf("delete after %s must be never, queue-length, or a number of messages", obj.Spec.DeleteAfter)
+		}
+	}
+	if obj.Spec.Source.Queue == "" && obj.Spec.Source.Exchange == "" {
+		return errors.New("source: one of queue or exchange is required")
+	}
+	err = obj.Spec.Source.Validate()
+	if err != nil {
+		return errors.Wrap(err, "source")
+	}
+	err = obj.Spec.Destination.Validate()
+	if err != nil {
+		return errors.Wrap(err, "destination")
+	}
+	return nil
+}
+
+// Validate checks one end of a shovel, also used by the operator before building the URI. The credentials end up in
+// the shovel parameter where anyone with access to the vhost can read them, so they must come from the endpoint itself
+// rather than the operator's or a cluster's admin account.
+func (endpoint *RabbitShovelEndpoint) Validate() error {
+	if endpoint.Connection != nil && endpoint.UserSecretRef != nil {
+		return errors.New("only one of connection and userSecretRef may be set")
+	}
+	if endpoint.Connection == nil && endpoint.UserSecretRef == nil {
+		return errors.New("one of connection and userSecretRef is required")
+	}
+	if endpoint.Connection != nil {
+		if endpoint.Connection.ClusterRef != nil {
+			return errors.New("connection clusterRef can't be used, set username and passwordSecretRef instead")
+		}
+		if endpoint.Connection.Username == "" || endpoint.Connection.PasswordSecretRef == nil {
+			return errors.New("connection username and passwordSecretRef are required")
+		}
+	}
+	if endpoint.Queue != "" && endpoint.Exchange != "" {
+		return errors.New("only one of queue and exchange may be set")
+	}
+	if endpoint.ExchangeKey != "" && endpoint.Exchange == "" {
+		return errors.New("exchangeKey requires exchange to be set")
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("RabbitShovel Webhook", func() {
+	var obj *RabbitShovel
+
+	BeforeEach(func() {
+		obj = &RabbitShovel{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing", Namespace: "default"},
+			Spec: RabbitShovelSpec{
+				Vhost:   "/",
+				AckMode: "on-confirm",
+				Source: RabbitShovelEndpoint{
+					UserSecretRef: &SecretRef{Name: "myuser-rabbituser"},
+					Queue:         "myqueue",
+				},
+				Destination: RabbitShovelEndpoint{
+					UserSecretRef: &SecretRef{Name: "myuser-rabbituser"},
+					Exchange:      "myexchange",
+				},
+			},
+		}
+	})
+
+	Describe("Default", func() {
+		It("sets the name if unset", func() {
+			obj.Default()
+			Expect(obj.Spec.ShovelName).To(Equal("testing"))
+		})
+
+		It("sets the ack mode if unset", func() {
+			obj.Spec.AckMode = ""
+			obj.Default()
+			Expect(obj.Spec.AckMode).To(Equal("on-confirm"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts a simple object", func() {
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+			err = obj.ValidateUpdate(obj)
+			Expect(err).ToNot(HaveOccurred())
+			err = obj.ValidateDelete()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects an unknown ack mode", func() {
+			obj.Spec.AckMode = "sometimes"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("ack mode sometimes is not a known mode"))
+		})
+
+		It("accepts a numeric delete after", func() {
+			obj.Spec.DeleteAfter = "100"
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects an invalid delete after", func() {
+			obj.Spec.DeleteAfter = "later"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("delete after later must be never, queue-length, or a number of messages"))
+		})
+
+		It("rejects a source without a queue or exchange", func() {
+			obj.Spec.Source.Queue = ""
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("source: one of queue or exchange is required"))
+		})
+
+		It("rejects an endpoint with both a connection and a user secret", func() {
+			obj.Spec.Destination.Connection = &RabbitConnection{Host: "otherhost"}
+			obj.Spec.Destination.UserSecretRef = &SecretRef{Name: "myuser-rabbituser"}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("destination: only one of connection and userSecretRef may be set"))
+		})
+
+		It("rejects an endpoint without credentials", func() {
+			obj.Spec.Source.UserSecretRef = nil
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("source: one of connection and userSecretRef is required"))
+		})
+
+		It("accepts a connection with its own credentials", func() {
+			obj.Spec.Destination.UserSecretRef = nil
+			obj.Spec.Destination.Connection = &RabbitConnection{Host: "otherhost", Username: "otheruser", PasswordSecretRef: &SecretRef{Name: "otheruser"}}
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects a connection without a password", func() {
+			obj.Spec.Destination.UserSecretRef = nil
+			obj.Spec.Destination.Connection = &RabbitConnection{Host: "otherhost", Username: "otheruser"}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("destination: connection username and passwordSecretRef are required"))
+		})
+
+		It("rejects a connection with a clusterRef", func() {
+			obj.Spec.Destination.UserSecretRef = nil
+			obj.Spec.Destination.Connection = &RabbitConnection{ClusterRef: &RabbitClusterRef{Name: "other"}, Username: "otheruser", PasswordSecretRef: &SecretRef{Name: "otheruser"}}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("destination: connection clusterRef can't be used, set username and passwordSecretRef instead"))
+		})
+	})
+})
@@ -18,6 +18,8 @@ limitations under the License.
 package v1beta1
 
 import (
+	"strings"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/coderanger/controller-utils/conditions"
@@ -35,24 +37,100 @@ type RabbitPermission struct {
 	Read string `json:"read,omitempty"`
 }
 
+// RabbitTopicPermission defines a single user topic permissions entry.
+type RabbitTopicPermission struct {
+	// Vhost this applies to.
+	Vhost string `json:"vhost"`
+	// Topic exchange this applies to.
+	Exchange string `json:"exchange"`
+	// Write permissions, matched against routing keys.
+	Write string `json:"write,omitempty"`
+	// Read permissions, matched against routing keys.
+	Read string `json:"read,omitempty"`
+}
+
+// Connection and channel limits for a user. Unset limits are cleared on the broker, -1 means unlimited.
+type RabbitUserLimits struct {
+	MaxConnections *int `json:"maxConnections,omitempty"`
+	MaxChannels    *int `json:"maxChannels,omitempty"`
+}
+
+// Scheduled or on-demand password rotation. Each rotation moves the credentials Secret onto a shadow user with a new
+// password and only deletes the previous user after the grace period, so clients can pick up the new Secret first.
+type RabbitPasswordRotation struct {
+	// How often to rotate the password. Unset only rotates when the rabbitmq.coderanger.net/rotate-password
+	// annotation changes.
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// How long the previous credentials keep working after the Secret is switched. Defaults to 1h.
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
 // RabbitUserSpec defines the desired state of RabbitUser
 type RabbitUserSpec struct {
-	Username    string             `json:"username,omitempty"`
-	Tags        string             `json:"tags,omitempty"`
-	Permissions []RabbitPermission `json:"permissions,omitempty"`
-	// TODO TopicPermissions
-	Connection RabbitConnection `json:"connection,omitempty"`
+	Username string `json:"username,omitempty"`
+	// Comma-separated tags for the user. Deprecated, use tagList instead. The defaulting webhook moves these into
+	// tagList, both are combined if it isn't running.
+	Tags string `json:"tags,omitempty"`
+	// Tags for the user, e.g. administrator or management.
+	TagList []string `json:"tagList,omitempty"`
+	// Allow tags other than the ones built into RabbitMQ, e.g. for plugins.
+	AllowCustomTags  bool                    `json:"allowCustomTags,omitempty"`
+	Permissions      []RabbitPermission      `json:"permissions,omitempty"`
+	TopicPermissions []RabbitTopicPermission `json:"topicPermissions,omitempty"`
+	Limits           *RabbitUserLimits       `json:"limits,omitempty"`
+	PasswordRotation *RabbitPasswordRotation `json:"passwordRotation,omitempty"`
+	// Secret in the same namespace to take the password from instead of generating one. Key defaults to password.
+	PasswordSecretRef *SecretRef `json:"passwordSecretRef,omitempty"`
+	// Password hashing algorithm: sha256, sha512, or md5 for legacy brokers. Defaults to the operator's
+	// --default-hashing-algorithm. Users hashed with anything else are re-hashed.
+	// +kubebuilder:validation:Enum=sha256;sha512;md5
+	HashingAlgorithm string `json:"hashingAlgorithm,omitempty"`
+	// Formats to write into the credentials Secret: rabbit for the RABBIT_* keys, spring for spring.rabbitmq.*
+	// properties, json for a rabbitmq.json blob, and amqpURL for AMQP_URL. Defaults to rabbit. RABBIT_PASSWORD is
+	// always included.
+	SecretFormats []string `json:"secretFormats,omitempty"`
+	// ConfigMap holding a template for an extra Secret, rendered with the same data as the credentials Secret but only
+	// sprig's hermetic functions. Key defaults to secret.yml.
+	SecretTemplateRef *ConfigMapRef `json:"secretTemplateRef,omitempty"`
+	// Manage a user that already exists on the broker rather than refusing to. The
+	// rabbitmq.coderanger.net/adopt annotation does the same.
+	Adopt          bool             `json:"adopt,omitempty"`
+	DeletionPolicy DeletionPolicy   `json:"deletionPolicy,omitempty"`
+	Connection     RabbitConnection `json:"connection,omitempty"`
+}
+
+// Progress of password rotation.
+type RabbitUserPasswordRotation struct {
+	// Current phase: Idle, or Retiring while the previous user is kept for the grace period.
+	Phase string `json:"phase"`
+	// When the Secret was last switched to a new password, or when rotation was turned on.
+	LastRotation metav1.Time `json:"lastRotation"`
+	// Annotation value the last manual rotation was done for.
+	Nonce string `json:"nonce,omitempty"`
+	// Set while the shadow user <username>-shadow holds the live credentials rather than the username itself.
+	Shadow bool `json:"shadow,omitempty"`
+	// Previous user to delete once the grace period is over.
+	RetiringUsername string `json:"retiringUsername,omitempty"`
 }
 
 // RabbitUserStatus defines the observed state of RabbitUser
 type RabbitUserStatus struct {
 	// Represents the observations of a RabbitUsers's current state.
-	// Known .status.conditions.type are: Ready, UserReady, PermissionsReady
+	// Known .status.conditions.type are: Ready, UserReady, PermissionsReady, TopicPermissionsReady, LimitsReady
 	// +patchMergeKey=type
 	// +patchStrategy=merge
 	// +listType=map
 	// +listMapKey=type
 	Conditions []conditions.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+	// Set once this object has created or adopted the user, only then is it deleted by the finalizer.
+	Owned bool `json:"owned,omitempty"`
+	// Set while this object hasn't created or adopted the user yet, or the user exists on the broker but this object
+	// isn't allowed to manage it.
+	OwnershipConflict bool `json:"ownershipConflict,omitempty"`
+	// Set once passwordRotation has been turned on.
+	PasswordRotation *RabbitUserPasswordRotation `json:"passwordRotation,omitempty"`
+	// Name of the Secret last rendered from secretTemplateRef, so it can be cleaned up if the name changes.
+	TemplateSecretName string `json:"templateSecretName,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -84,3 +162,37 @@ func init() {
 func (o *RabbitUser) GetConditions() *[]conditions.Condition {
 	return &o.Status.Conditions
 }
+
+// Operator-wide password hashing algorithm, used when hashingAlgorithm isn't set.
+var DefaultHashingAlgorithm = "sha256"
+
+// GetHashingAlgorithm returns the password hashing algorithm to use for this user.
+func (o *RabbitUser) GetHashingAlgorithm() string {
+	if o.Spec.HashingAlgorithm != "" {
+		return o.Spec.HashingAlgorithm
+	}
+	return DefaultHashingAlgorithm
+}
+
+// Suffix for the shadow user which trades places with the main username on each password rotation.
+const ShadowUserSuffix = "-shadow"
+
+// ActiveUsername returns the broker user the credentials Secret currently points at.
+func (o *RabbitUser) ActiveUsername() string {
+	if o.Status.PasswordRotation != nil && o.Status.PasswordRotation.Shadow {
+		return o.Spec.Username + ShadowUserSuffix
+	}
+	return o.Spec.Username
+}
+
+// ResolveTags returns the user's tags from both tagList and the deprecated comma-separated tags string.
+func (o *RabbitUser) ResolveTags() []string {
+	tags := append([]string{}, o.Spec.TagList...)
+	for _, tag := range strings.Split(o.Spec.Tags, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -17,7 +17,12 @@ limitations under the License.
 package v1beta1
 
 import (
+	"reflect"
+	"strings"
+	"time"
+
 	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -37,6 +42,28 @@ func (obj *RabbitUser) Default() {
 	if obj.Spec.Username == "" {
 		obj.Spec.Username = obj.Name
 	}
+	if obj.Spec.DeletionPolicy == "" {
+		obj.Spec.DeletionPolicy = "Delete"
+	}
+	if len(obj.Spec.SecretFormats) == 0 {
+		obj.Spec.SecretFormats = []string{"rabbit"}
+	}
+	if obj.Spec.PasswordRotation != nil && obj.Spec.PasswordRotation.GracePeriod == nil {
+		obj.Spec.PasswordRotation.GracePeriod = &metav1.Duration{Duration: time.Hour}
+	}
+	// Move the deprecated tags string into tagList.
+	if obj.Spec.Tags != "" {
+		seen := map[string]bool{}
+		tagList := []string{}
+		for _, tag := range obj.ResolveTags() {
+			if !seen[tag] {
+				seen[tag] = true
+				tagList = append(tagList, tag)
+			}
+		}
+		obj.Spec.TagList = tagList
+		obj.Spec.Tags = ""
+	}
 }
 
 // +kubebuilder:webhook:path=/validate-rabbitmq-coderanger-net-v1beta1-rabbituser,mutating=true,failurePolicy=fail,sideEffects=None,groups=rabbitmq.coderanger.net,resources=rabbitusers,verbs=create;update,versions=v1beta1,name=vrabbituser.kb.io,admissionReviewVersions=v1beta1
@@ -46,13 +73,27 @@ var _ webhook.Validator = &RabbitUser{}
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
 func (obj *RabbitUser) ValidateCreate() error {
 	rabbitUserLog.Info("validate create", "name", obj.Name, "namespace", obj.Namespace)
-	return obj.validate()
+	err := obj.validate()
+	if err != nil {
+		return err
+	}
+	return obj.validateTags()
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
 func (obj *RabbitUser) ValidateUpdate(old runtime.Object) error {
 	rabbitUserLog.Info("validate update", "name", obj.Name, "namespace", obj.Namespace)
-	return obj.validate()
+	err := obj.validate()
+	if err != nil {
+		return err
+	}
+	// Only check tags when they change, so users from before the check (or with tags from since-removed plugins) can
+	// still be updated and have their finalizers removed.
+	oldUser, ok := old.(*RabbitUser)
+	if ok && reflect.DeepEqual(oldUser.ResolveTags(), obj.ResolveTags()) {
+		return nil
+	}
+	return obj.validateTags()
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type. Not used, just here for interface compliance.
@@ -61,6 +102,34 @@ func (obj *RabbitUser) ValidateDelete() error {
 }
 
 func (obj *RabbitUser) validate() error {
+	err := ValidateConnection(&obj.Spec.Connection)
+	if err != nil {
+		return err
+	}
+
+	err = validateDeletionPolicy(obj.Spec.DeletionPolicy)
+	if err != nil {
+		return err
+	}
+
+	if obj.Spec.HashingAlgorithm != "" {
+		err = ValidateHashingAlgorithm(obj.Spec.HashingAlgorithm)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, format := range obj.Spec.SecretFormats {
+		switch format {
+		case "rabbit", "spring", "json", "amqpURL":
+		default:
+			return errors.Errorf("secret format %s is not a known format", format)
+		}
+	}
+	if obj.Spec.SecretTemplateRef != nil && obj.Spec.SecretTemplateRef.Name == "" {
+		return errors.New("secretTemplateRef name must be set")
+	}
+
 	// Confirm that each vhost appears only once because that's how Rabbit permissions work.
 	seenVhosts := map[string]bool{}
 	for _, perm := range obj.Spec.Permissions {
@@ -71,5 +140,72 @@ func (obj *RabbitUser) validate() error {
 		seenVhosts[perm.Vhost] = true
 	}
 
+	// Same for topic permissions, but per vhost and exchange.
+	seenExchanges := map[string]bool{}
+	for _, perm := range obj.Spec.TopicPermissions {
+		key := perm.Vhost + "/" + perm.Exchange
+		_, ok := seenExchanges[key]
+		if ok {
+			return errors.Errorf("Duplicate topic permissions for exchange %s in vhost %s", perm.Exchange, perm.Vhost)
+		}
+		seenExchanges[key] = true
+	}
+
+	// Validate limits, -1 is the only allowed negative value and means unlimited.
+	if obj.Spec.Limits != nil {
+		if obj.Spec.Limits.MaxConnections != nil && *obj.Spec.Limits.MaxConnections < -1 {
+			return errors.Errorf("limit maxConnections must be -1 or non-negative: %d", *obj.Spec.Limits.MaxConnections)
+		}
+		if obj.Spec.Limits.MaxChannels != nil && *obj.Spec.Limits.MaxChannels < -1 {
+			return errors.Errorf("limit maxChannels must be -1 or non-negative: %d", *obj.Spec.Limits.MaxChannels)
+		}
+	}
+
+	// The old credentials have to be gone before the next rotation can start.
+	rotation := obj.Spec.PasswordRotation
+	if rotation != nil {
+		if obj.Spec.PasswordSecretRef != nil {
+			return errors.New("passwordRotation and passwordSecretRef are mutually exclusive")
+		}
+		if rotation.GracePeriod != nil && rotation.GracePeriod.Duration < 0 {
+			return errors.Errorf("passwordRotation gracePeriod %s must not be negative", rotation.GracePeriod.Duration)
+		}
+		if rotation.Interval != nil && rotation.GracePeriod != nil && rotation.Interval.Duration <= rotation.GracePeriod.Duration {
+			return errors.Errorf("passwordRotation interval %s must be longer than the gracePeriod %s", rotation.Interval.Duration, rotation.GracePeriod.Duration)
+		}
+	}
+
 	return nil
 }
+
+func (obj *RabbitUser) validateTags() error {
+	for _, tag := range obj.ResolveTags() {
+		// Same prefix as the operator uses to mark the users it manages.
+		if strings.HasPrefix(tag, "rabbitmq-operator-owner:") {
+			return errors.Errorf("tag %s is reserved for the operator", tag)
+		}
+		if !builtinUserTags[tag] && !obj.Spec.AllowCustomTags {
+			return errors.Errorf("tag %s is not a built-in tag, set allowCustomTags to use it", tag)
+		}
+	}
+	return nil
+}
+
+// Tags RabbitMQ understands without any plugins.
+var builtinUserTags = map[string]bool{
+	"administrator": true,
+	"monitoring":    true,
+	"policymaker":   true,
+	"management":    true,
+	"impersonator":  true,
+}
+
+// ValidateHashingAlgorithm checks a password hashing algorithm name, for both users and the operator-wide default.
+func ValidateHashingAlgorithm(algorithm string) error {
+	switch algorithm {
+	case "sha256", "sha512", "md5":
+		return nil
+	default:
+		return errors.Errorf("hashing algorithm %s is not a known algorithm", algorithm)
+	}
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package v1beta1
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,6 +31,7 @@ var _ = Describe("RabbitUser Webhook", func() {
 		obj = &RabbitUser{
 			ObjectMeta: metav1.ObjectMeta{Name: "testing", Namespace: "default"},
 			Spec: RabbitUserSpec{
+				DeletionPolicy: "Delete",
 				Connection: RabbitConnection{
 					Host:     "testhost",
 					Username: "testuser",
@@ -51,11 +54,64 @@ var _ = Describe("RabbitUser Webhook", func() {
 			Expect(obj.Spec.Username).To(Equal("testing"))
 		})
 
+		It("sets the deletion policy if unset", func() {
+			obj.Spec.DeletionPolicy = ""
+			obj.Default()
+			Expect(obj.Spec.DeletionPolicy).To(BeEquivalentTo("Delete"))
+		})
+
+		It("sets the rotation grace period if unset", func() {
+			obj.Spec.PasswordRotation = &RabbitPasswordRotation{}
+			obj.Default()
+			Expect(obj.Spec.PasswordRotation.GracePeriod).To(Equal(&metav1.Duration{Duration: time.Hour}))
+		})
+
+		It("sets the secret formats if unset", func() {
+			obj.Default()
+			Expect(obj.Spec.SecretFormats).To(Equal([]string{"rabbit"}))
+		})
+
+		It("does not set the secret formats if set", func() {
+			obj.Spec.SecretFormats = []string{"spring"}
+			obj.Default()
+			Expect(obj.Spec.SecretFormats).To(Equal([]string{"spring"}))
+		})
+
 		It("does not set the name if set", func() {
 			obj.Spec.Username = "other"
 			obj.Default()
 			Expect(obj.Spec.Username).To(Equal("other"))
 		})
+
+		It("moves the deprecated tags into the tag list", func() {
+			obj.Spec.TagList = []string{"management"}
+			obj.Spec.Tags = "management, policymaker"
+			obj.Default()
+			Expect(obj.Spec.TagList).To(Equal([]string{"management", "policymaker"}))
+			Expect(obj.Spec.Tags).To(BeEmpty())
+		})
+	})
+
+	Describe("ResolveTags", func() {
+		It("uses the tag list", func() {
+			obj.Spec.TagList = []string{"administrator", "management"}
+			Expect(obj.ResolveTags()).To(Equal([]string{"administrator", "management"}))
+		})
+
+		It("splits the deprecated string", func() {
+			obj.Spec.Tags = "administrator, management"
+			Expect(obj.ResolveTags()).To(Equal([]string{"administrator", "management"}))
+		})
+
+		It("combines both", func() {
+			obj.Spec.TagList = []string{"administrator"}
+			obj.Spec.Tags = "management"
+			Expect(obj.ResolveTags()).To(Equal([]string{"administrator", "management"}))
+		})
+
+		It("handles no tags", func() {
+			Expect(obj.ResolveTags()).To(BeEmpty())
+		})
 	})
 
 	Describe("Validate", func() {
@@ -68,6 +124,12 @@ var _ = Describe("RabbitUser Webhook", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("rejects an unknown deletion policy", func() {
+			obj.Spec.DeletionPolicy = "Archive"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("deletion policy Archive is not a known policy"))
+		})
+
 		It("rejects double permissions", func() {
 			obj.Spec.Permissions = append(obj.Spec.Permissions, RabbitPermission{
 				Vhost: "/",
@@ -75,5 +137,140 @@ var _ = Describe("RabbitUser Webhook", func() {
 			err := obj.ValidateCreate()
 			Expect(err).To(MatchError("Duplicate permissions for vhost /"))
 		})
+
+		It("accepts topic permissions for multiple exchanges in the same vhost", func() {
+			obj.Spec.TopicPermissions = []RabbitTopicPermission{
+				{Vhost: "/", Exchange: "one", Write: ".*"},
+				{Vhost: "/", Exchange: "two", Write: ".*"},
+			}
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects double topic permissions", func() {
+			obj.Spec.TopicPermissions = []RabbitTopicPermission{
+				{Vhost: "/", Exchange: "one", Write: ".*"},
+				{Vhost: "/", Exchange: "one", Read: ".*"},
+			}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("Duplicate topic permissions for exchange one in vhost /"))
+		})
+
+		It("accepts limits", func() {
+			maxConnections := 10
+			unlimited := -1
+			obj.Spec.Limits = &RabbitUserLimits{MaxConnections: &maxConnections, MaxChannels: &unlimited}
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects a negative maxChannels limit", func() {
+			maxChannels := -5
+			obj.Spec.Limits = &RabbitUserLimits{MaxChannels: &maxChannels}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("limit maxChannels must be -1 or non-negative: -5"))
+		})
+
+		It("accepts built-in tags", func() {
+			obj.Spec.TagList = []string{"management", "policymaker"}
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects a custom tag", func() {
+			obj.Spec.TagList = []string{"management", "federation"}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("tag federation is not a built-in tag, set allowCustomTags to use it"))
+		})
+
+		It("accepts a custom tag with allowCustomTags", func() {
+			obj.Spec.TagList = []string{"federation"}
+			obj.Spec.AllowCustomTags = true
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects the owner tag", func() {
+			obj.Spec.TagList = []string{"rabbitmq-operator-owner:other"}
+			obj.Spec.AllowCustomTags = true
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("tag rabbitmq-operator-owner:other is reserved for the operator"))
+		})
+
+		It("rejects a custom tag in the deprecated string", func() {
+			obj.Spec.Tags = "management,federation"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("tag federation is not a built-in tag, set allowCustomTags to use it"))
+		})
+
+		It("accepts an update that leaves existing custom tags alone", func() {
+			obj.Spec.Tags = "federation"
+			old := obj.DeepCopy()
+			obj.Finalizers = []string{"rabbituser.rabbitmq.coderanger.net/user"}
+			err := obj.ValidateUpdate(old)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects an update adding a custom tag", func() {
+			old := obj.DeepCopy()
+			obj.Spec.TagList = []string{"federation"}
+			err := obj.ValidateUpdate(old)
+			Expect(err).To(MatchError("tag federation is not a built-in tag, set allowCustomTags to use it"))
+		})
+
+		It("accepts a hashing algorithm", func() {
+			obj.Spec.HashingAlgorithm = "sha512"
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects an unknown hashing algorithm", func() {
+			obj.Spec.HashingAlgorithm = "bcrypt"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("hashing algorithm bcrypt is not a known algorithm"))
+		})
+
+		It("accepts secret formats", func() {
+			obj.Spec.SecretFormats = []string{"rabbit", "spring", "json", "amqpURL"}
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects an unknown secret format", func() {
+			obj.Spec.SecretFormats = []string{"dotenv"}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("secret format dotenv is not a known format"))
+		})
+
+		It("rejects a secret template without a name", func() {
+			obj.Spec.SecretTemplateRef = &ConfigMapRef{Key: "secret.yml"}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("secretTemplateRef name must be set"))
+		})
+
+		It("accepts password rotation", func() {
+			obj.Spec.PasswordRotation = &RabbitPasswordRotation{
+				Interval:    &metav1.Duration{Duration: 90 * 24 * time.Hour},
+				GracePeriod: &metav1.Duration{Duration: time.Hour},
+			}
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects password rotation with a password secret", func() {
+			obj.Spec.PasswordRotation = &RabbitPasswordRotation{}
+			obj.Spec.PasswordSecretRef = &SecretRef{Name: "vault-password"}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("passwordRotation and passwordSecretRef are mutually exclusive"))
+		})
+
+		It("rejects a rotation interval shorter than the grace period", func() {
+			obj.Spec.PasswordRotation = &RabbitPasswordRotation{
+				Interval:    &metav1.Duration{Duration: time.Minute},
+				GracePeriod: &metav1.Duration{Duration: time.Hour},
+			}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("passwordRotation interval 1m0s must be longer than the gracePeriod 1h0m0s"))
+		})
 	})
 })
@@ -38,23 +38,49 @@ type RabbitPolicy struct {
 	Definition runtime.RawExtension `json:"definition"`
 }
 
+// Connection and queue limits for a vhost. Unset limits are cleared on the broker, -1 means unlimited.
+type RabbitVhostLimits struct {
+	MaxConnections *int `json:"maxConnections,omitempty"`
+	MaxQueues      *int `json:"maxQueues,omitempty"`
+}
+
 // RabbitVhostSpec defines the desired state of RabbitVhost
 type RabbitVhostSpec struct {
-	VhostName  string                  `json:"vhostName,omitempty"`
-	SkipUser   bool                    `json:"skipUser,omitempty"`
-	Policies   map[string]RabbitPolicy `json:"policies,omitempty"`
-	Connection RabbitConnection        `json:"connection,omitempty"`
+	VhostName string `json:"vhostName,omitempty"`
+	SkipUser  bool   `json:"skipUser,omitempty"`
+	// Description and tags are informational metadata shown in the management UI.
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// Queue type used when a client declares a queue without x-queue-type: classic, quorum, or stream.
+	DefaultQueueType string `json:"defaultQueueType,omitempty"`
+	// Enable the firehose tracer for this vhost.
+	Tracing  bool                    `json:"tracing,omitempty"`
+	Policies map[string]RabbitPolicy `json:"policies,omitempty"`
+	// Operator policies, which cap settings like max-length and message-ttl regardless of regular policies. They only
+	// apply to queues, so applyTo must be unset or queues.
+	OperatorPolicies map[string]RabbitPolicy `json:"operatorPolicies,omitempty"`
+	Limits           *RabbitVhostLimits      `json:"limits,omitempty"`
+	// Manage a vhost that already exists on the broker rather than refusing to. The
+	// rabbitmq.coderanger.net/adopt annotation does the same.
+	Adopt          bool             `json:"adopt,omitempty"`
+	DeletionPolicy DeletionPolicy   `json:"deletionPolicy,omitempty"`
+	Connection     RabbitConnection `json:"connection,omitempty"`
 }
 
 // RabbitVhostStatus defines the observed state of RabbitVhost
 type RabbitVhostStatus struct {
 	// Represents the observations of a RabbitUsers's current state.
-	// Known .status.conditions.type are: Ready, VhostReady, PoliciesReady, UserReady
+	// Known .status.conditions.type are: Ready, VhostReady, PoliciesReady, OperatorPoliciesReady, LimitsReady, UserReady
 	// +patchMergeKey=type
 	// +patchStrategy=merge
 	// +listType=map
 	// +listMapKey=type
 	Conditions []conditions.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+	// Set once this object has created or adopted the vhost, only then is it deleted by the finalizer.
+	Owned bool `json:"owned,omitempty"`
+	// Set while this object hasn't created or adopted the vhost yet, or the vhost exists on the broker but this object
+	// isn't allowed to manage it.
+	OwnershipConflict bool `json:"ownershipConflict,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -39,6 +39,16 @@ func (obj *RabbitVhost) Default() {
 	if obj.Spec.VhostName == "" {
 		obj.Spec.VhostName = obj.Name
 	}
+	if obj.Spec.DeletionPolicy == "" {
+		obj.Spec.DeletionPolicy = "Delete"
+	}
+	// Operator policies can only apply to queues, and RabbitMQ reports that explicitly.
+	for name, policy := range obj.Spec.OperatorPolicies {
+		if policy.ApplyTo == "" {
+			policy.ApplyTo = "queues"
+			obj.Spec.OperatorPolicies[name] = policy
+		}
+	}
 }
 
 // +kubebuilder:webhook:path=/validate-rabbitmq-coderanger-net-v1beta1-rabbitvhost,mutating=true,failurePolicy=fail,sideEffects=None,groups=rabbitmq.coderanger.net,resources=rabbitvhosts,verbs=create;update,versions=v1beta1,name=vrabbitvhost.kb.io,admissionReviewVersions=v1beta1
@@ -63,6 +73,22 @@ func (obj *RabbitVhost) ValidateDelete() error {
 }
 
 func (obj *RabbitVhost) validate() error {
+	err := ValidateConnection(&obj.Spec.Connection)
+	if err != nil {
+		return err
+	}
+
+	err = validateDeletionPolicy(obj.Spec.DeletionPolicy)
+	if err != nil {
+		return err
+	}
+
+	switch obj.Spec.DefaultQueueType {
+	case "", "classic", "quorum", "stream":
+	default:
+		return errors.Errorf("default queue type %s is not a known queue type", obj.Spec.DefaultQueueType)
+	}
+
 	// Validate policies.
 	for name, specPolicy := range obj.Spec.Policies {
 		var definition map[string]interface{}
@@ -91,5 +117,41 @@ func (obj *RabbitVhost) validate() error {
 			}
 		}
 	}
+
+	// Validate operator policies, which only support a few numeric keys.
+	for name, specPolicy := range obj.Spec.OperatorPolicies {
+		// RabbitMQ only applies operator policies to queues.
+		switch specPolicy.ApplyTo {
+		case "", "queues":
+		default:
+			return errors.Errorf("operator policy %s applyTo must be queues, not %s", name, specPolicy.ApplyTo)
+		}
+		var definition map[string]interface{}
+		err := json.Unmarshal(specPolicy.Definition.Raw, &definition)
+		if err != nil {
+			return errors.Wrapf(err, "error parsing operator policy definition %s", name)
+		}
+		for key, val := range definition {
+			switch key {
+			case "expires", "message-ttl", "max-length", "max-length-bytes", "max-in-memory-length", "max-in-memory-bytes", "delivery-limit":
+				numVal, ok := val.(float64)
+				if !ok || numVal < 0 {
+					return errors.Errorf("operator policy %s %s value is not a non-negative number: %#v", name, key, val)
+				}
+			default:
+				return errors.Errorf("operator policy %s %s is not supported in operator policies", name, key)
+			}
+		}
+	}
+
+	// Validate limits, -1 is the only allowed negative value and means unlimited.
+	if obj.Spec.Limits != nil {
+		if obj.Spec.Limits.MaxConnections != nil && *obj.Spec.Limits.MaxConnections < -1 {
+			return errors.Errorf("limit maxConnections must be -1 or non-negative: %d", *obj.Spec.Limits.MaxConnections)
+		}
+		if obj.Spec.Limits.MaxQueues != nil && *obj.Spec.Limits.MaxQueues < -1 {
+			return errors.Errorf("limit maxQueues must be -1 or non-negative: %d", *obj.Spec.Limits.MaxQueues)
+		}
+	}
 	return nil
 }
@@ -30,6 +30,7 @@ var _ = Describe("RabbitVhost Webhook", func() {
 		obj = &RabbitVhost{
 			ObjectMeta: metav1.ObjectMeta{Name: "testing", Namespace: "default"},
 			Spec: RabbitVhostSpec{
+				DeletionPolicy: "Delete",
 				Connection: RabbitConnection{
 					Host:     "testhost",
 					Username: "testuser",
@@ -45,11 +46,25 @@ var _ = Describe("RabbitVhost Webhook", func() {
 			Expect(obj.Spec.VhostName).To(Equal("testing"))
 		})
 
+		It("sets the deletion policy if unset", func() {
+			obj.Spec.DeletionPolicy = ""
+			obj.Default()
+			Expect(obj.Spec.DeletionPolicy).To(BeEquivalentTo("Delete"))
+		})
+
 		It("does not set the name if set", func() {
 			obj.Spec.VhostName = "other"
 			obj.Default()
 			Expect(obj.Spec.VhostName).To(Equal("other"))
 		})
+
+		It("sets operator policies to apply to queues", func() {
+			obj.Spec.OperatorPolicies = map[string]RabbitPolicy{
+				"limits": {Pattern: ".*"},
+			}
+			obj.Default()
+			Expect(obj.Spec.OperatorPolicies["limits"].ApplyTo).To(Equal("queues"))
+		})
 	})
 
 	Describe("Validate", func() {
@@ -127,5 +142,97 @@ var _ = Describe("RabbitVhost Webhook", func() {
 			err := obj.ValidateCreate()
 			Expect(err).To(MatchError("policy bad asdf value is not a string, boolean, or number: []interface {}{}"))
 		})
+
+		It("accepts an operator policy", func() {
+			obj.Spec.OperatorPolicies = map[string]RabbitPolicy{
+				"limits": {
+					Pattern: ".*",
+					Definition: runtime.RawExtension{
+						Raw: []byte(`{"max-length": 1000, "message-ttl": 60000}`),
+					},
+				},
+			}
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects an unsupported operator policy key", func() {
+			obj.Spec.OperatorPolicies = map[string]RabbitPolicy{
+				"limits": {
+					Pattern: ".*",
+					Definition: runtime.RawExtension{
+						Raw: []byte(`{"ha-mode": "all"}`),
+					},
+				},
+			}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("operator policy limits ha-mode is not supported in operator policies"))
+		})
+
+		It("rejects a negative operator policy value", func() {
+			obj.Spec.OperatorPolicies = map[string]RabbitPolicy{
+				"limits": {
+					Pattern: ".*",
+					Definition: runtime.RawExtension{
+						Raw: []byte(`{"max-length": -1}`),
+					},
+				},
+			}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("operator policy limits max-length value is not a non-negative number: -1"))
+		})
+
+		It("rejects an operator policy applied to exchanges", func() {
+			obj.Spec.OperatorPolicies = map[string]RabbitPolicy{
+				"limits": {
+					Pattern: ".*",
+					ApplyTo: "exchanges",
+					Definition: runtime.RawExtension{
+						Raw: []byte(`{"max-length": 1000}`),
+					},
+				},
+			}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("operator policy limits applyTo must be queues, not exchanges"))
+		})
+
+		It("accepts the DeleteIfEmpty deletion policy", func() {
+			obj.Spec.DeletionPolicy = "DeleteIfEmpty"
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("accepts a known default queue type", func() {
+			obj.Spec.DefaultQueueType = "quorum"
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects an unknown default queue type", func() {
+			obj.Spec.DefaultQueueType = "lazy"
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("default queue type lazy is not a known queue type"))
+		})
+
+		It("accepts unlimited limits", func() {
+			unlimited := -1
+			obj.Spec.Limits = &RabbitVhostLimits{MaxConnections: &unlimited, MaxQueues: &unlimited}
+			err := obj.ValidateCreate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects a negative maxConnections limit", func() {
+			maxConnections := -2
+			obj.Spec.Limits = &RabbitVhostLimits{MaxConnections: &maxConnections}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("limit maxConnections must be -1 or non-negative: -2"))
+		})
+
+		It("rejects a negative maxQueues limit", func() {
+			maxQueues := -10
+			obj.Spec.Limits = &RabbitVhostLimits{MaxQueues: &maxQueues}
+			err := obj.ValidateCreate()
+			Expect(err).To(MatchError("limit maxQueues must be -1 or non-negative: -10"))
+		})
 	})
 })
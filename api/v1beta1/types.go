@@ -21,11 +21,35 @@ type SecretRef struct {
 	Key  string `json:"key,omitempty"`
 }
 
+// Reference to a ConfigMap key in the same namespace.
+type ConfigMapRef struct {
+	Name string `json:"name"`
+	Key  string `json:"key,omitempty"`
+}
+
+// Reference to a Secret in a specific namespace, used by cluster-scoped objects.
+type ClusterSecretRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Key       string `json:"key,omitempty"`
+}
+
+type RabbitClusterRef struct {
+	Name string `json:"name"`
+}
+
+// What to do with the broker object when a RabbitQueue, RabbitVhost, or RabbitUser is deleted. DeleteIfEmpty waits
+// until there are no messages left, or for users, no open connections.
+// +kubebuilder:validation:Enum=Delete;Retain;DeleteIfEmpty
+type DeletionPolicy string
+
 type RabbitConnection struct {
-	Protocol           string     `json:"protocol,omitempty"`
-	Host               string     `json:"host,omitempty"`
-	Port               int        `json:"port,omitempty"`
-	Username           string     `json:"username,omitempty"`
-	PasswordSecretRef  *SecretRef `json:"passwordSecretRef,omitempty"`
-	InsecureSkipVerify *bool      `json:"insecureSkipVerify,omitempty"`
+	// Use the endpoint and admin credentials from a RabbitCluster. Only username and passwordSecretRef may be set alongside it.
+	ClusterRef         *RabbitClusterRef `json:"clusterRef,omitempty"`
+	Protocol           string            `json:"protocol,omitempty"`
+	Host               string            `json:"host,omitempty"`
+	Port               int               `json:"port,omitempty"`
+	Username           string            `json:"username,omitempty"`
+	PasswordSecretRef  *SecretRef        `json:"passwordSecretRef,omitempty"`
+	InsecureSkipVerify *bool             `json:"insecureSkipVerify,omitempty"`
 }
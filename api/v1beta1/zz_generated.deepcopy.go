@@ -22,67 +22,918 @@ package v1beta1
 
 import (
 	"github.com/coderanger/controller-utils/conditions"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSecretRef) DeepCopyInto(out *ClusterSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSecretRef.
+func (in *ClusterSecretRef) DeepCopy() *ClusterSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapRef) DeepCopyInto(out *ConfigMapRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapRef.
+func (in *ConfigMapRef) DeepCopy() *ConfigMapRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitBinding) DeepCopyInto(out *RabbitBinding) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitBinding.
+func (in *RabbitBinding) DeepCopy() *RabbitBinding {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitBinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RabbitBinding) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitBindingList) DeepCopyInto(out *RabbitBindingList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RabbitBinding, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitBindingList.
+func (in *RabbitBindingList) DeepCopy() *RabbitBindingList {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitBindingList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RabbitBindingList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitBindingRef) DeepCopyInto(out *RabbitBindingRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitBindingRef.
+func (in *RabbitBindingRef) DeepCopy() *RabbitBindingRef {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitBindingRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitBindingSpec) DeepCopyInto(out *RabbitBindingSpec) {
+	*out = *in
+	if in.Arguments != nil {
+		in, out := &in.Arguments, &out.Arguments
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Connection.DeepCopyInto(&out.Connection)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitBindingSpec.
+func (in *RabbitBindingSpec) DeepCopy() *RabbitBindingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitBindingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitBindingStatus) DeepCopyInto(out *RabbitBindingStatus) {
+	*out = *in
+	if in.Binding != nil {
+		in, out := &in.Binding, &out.Binding
+		*out = new(RabbitBindingRef)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]conditions.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitBindingStatus.
+func (in *RabbitBindingStatus) DeepCopy() *RabbitBindingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitBindingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitCluster) DeepCopyInto(out *RabbitCluster) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitCluster.
+func (in *RabbitCluster) DeepCopy() *RabbitCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RabbitCluster) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitClusterList) DeepCopyInto(out *RabbitClusterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RabbitCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitClusterList.
+func (in *RabbitClusterList) DeepCopy() *RabbitClusterList {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitClusterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RabbitClusterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitClusterRef) DeepCopyInto(out *RabbitClusterRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitClusterRef.
+func (in *RabbitClusterRef) DeepCopy() *RabbitClusterRef {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitClusterRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitClusterSpec) DeepCopyInto(out *RabbitClusterSpec) {
+	*out = *in
+	if in.PasswordSecretRef != nil {
+		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(ClusterSecretRef)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(RabbitClusterTLS)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitClusterSpec.
+func (in *RabbitClusterSpec) DeepCopy() *RabbitClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitClusterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitClusterStatus) DeepCopyInto(out *RabbitClusterStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]conditions.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitClusterStatus.
+func (in *RabbitClusterStatus) DeepCopy() *RabbitClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitClusterTLS) DeepCopyInto(out *RabbitClusterTLS) {
+	*out = *in
+	if in.CASecretRef != nil {
+		in, out := &in.CASecretRef, &out.CASecretRef
+		*out = new(ClusterSecretRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitClusterTLS.
+func (in *RabbitClusterTLS) DeepCopy() *RabbitClusterTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitClusterTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RabbitConnection) DeepCopyInto(out *RabbitConnection) {
 	*out = *in
-	if in.PasswordSecretRef != nil {
-		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
-		*out = new(SecretRef)
+	if in.ClusterRef != nil {
+		in, out := &in.ClusterRef, &out.ClusterRef
+		*out = new(RabbitClusterRef)
+		**out = **in
+	}
+	if in.PasswordSecretRef != nil {
+		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(SecretRef)
+		**out = **in
+	}
+	if in.InsecureSkipVerify != nil {
+		in, out := &in.InsecureSkipVerify, &out.InsecureSkipVerify
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitConnection.
+func (in *RabbitConnection) DeepCopy() *RabbitConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitDeadLetter) DeepCopyInto(out *RabbitDeadLetter) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitDeadLetter.
+func (in *RabbitDeadLetter) DeepCopy() *RabbitDeadLetter {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitDeadLetter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitExchange) DeepCopyInto(out *RabbitExchange) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitExchange.
+func (in *RabbitExchange) DeepCopy() *RabbitExchange {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitExchange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RabbitExchange) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitExchangeList) DeepCopyInto(out *RabbitExchangeList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RabbitExchange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitExchangeList.
+func (in *RabbitExchangeList) DeepCopy() *RabbitExchangeList {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitExchangeList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RabbitExchangeList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitExchangeSpec) DeepCopyInto(out *RabbitExchangeSpec) {
+	*out = *in
+	if in.Durable != nil {
+		in, out := &in.Durable, &out.Durable
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AutoDelete != nil {
+		in, out := &in.AutoDelete, &out.AutoDelete
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Internal != nil {
+		in, out := &in.Internal, &out.Internal
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Arguments != nil {
+		in, out := &in.Arguments, &out.Arguments
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Connection.DeepCopyInto(&out.Connection)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitExchangeSpec.
+func (in *RabbitExchangeSpec) DeepCopy() *RabbitExchangeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitExchangeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitExchangeStatus) DeepCopyInto(out *RabbitExchangeStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]conditions.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitExchangeStatus.
+func (in *RabbitExchangeStatus) DeepCopy() *RabbitExchangeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitExchangeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitFederationUpstream) DeepCopyInto(out *RabbitFederationUpstream) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitFederationUpstream.
+func (in *RabbitFederationUpstream) DeepCopy() *RabbitFederationUpstream {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitFederationUpstream)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RabbitFederationUpstream) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitFederationUpstreamList) DeepCopyInto(out *RabbitFederationUpstreamList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RabbitFederationUpstream, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitFederationUpstreamList.
+func (in *RabbitFederationUpstreamList) DeepCopy() *RabbitFederationUpstreamList {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitFederationUpstreamList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RabbitFederationUpstreamList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitFederationUpstreamSpec) DeepCopyInto(out *RabbitFederationUpstreamSpec) {
+	*out = *in
+	out.URISecretRef = in.URISecretRef
+	if in.Expires != nil {
+		in, out := &in.Expires, &out.Expires
+		*out = new(int)
+		**out = **in
+	}
+	if in.MessageTTL != nil {
+		in, out := &in.MessageTTL, &out.MessageTTL
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxHops != nil {
+		in, out := &in.MaxHops, &out.MaxHops
+		*out = new(int)
+		**out = **in
+	}
+	if in.PrefetchCount != nil {
+		in, out := &in.PrefetchCount, &out.PrefetchCount
+		*out = new(int)
+		**out = **in
+	}
+	if in.ReconnectDelay != nil {
+		in, out := &in.ReconnectDelay, &out.ReconnectDelay
+		*out = new(int)
+		**out = **in
+	}
+	if in.TrustUserID != nil {
+		in, out := &in.TrustUserID, &out.TrustUserID
+		*out = new(bool)
+		**out = **in
+	}
+	in.Connection.DeepCopyInto(&out.Connection)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitFederationUpstreamSpec.
+func (in *RabbitFederationUpstreamSpec) DeepCopy() *RabbitFederationUpstreamSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitFederationUpstreamSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitFederationUpstreamStatus) DeepCopyInto(out *RabbitFederationUpstreamStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]conditions.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitFederationUpstreamStatus.
+func (in *RabbitFederationUpstreamStatus) DeepCopy() *RabbitFederationUpstreamStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitFederationUpstreamStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitPasswordRotation) DeepCopyInto(out *RabbitPasswordRotation) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitPasswordRotation.
+func (in *RabbitPasswordRotation) DeepCopy() *RabbitPasswordRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitPasswordRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitPermission) DeepCopyInto(out *RabbitPermission) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitPermission.
+func (in *RabbitPermission) DeepCopy() *RabbitPermission {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitPermission)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitPolicy) DeepCopyInto(out *RabbitPolicy) {
+	*out = *in
+	in.Definition.DeepCopyInto(&out.Definition)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitPolicy.
+func (in *RabbitPolicy) DeepCopy() *RabbitPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitQueue) DeepCopyInto(out *RabbitQueue) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitQueue.
+func (in *RabbitQueue) DeepCopy() *RabbitQueue {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitQueue)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RabbitQueue) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitQueueList) DeepCopyInto(out *RabbitQueueList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RabbitQueue, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitQueueList.
+func (in *RabbitQueueList) DeepCopy() *RabbitQueueList {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitQueueList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RabbitQueueList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitQueueMigration) DeepCopyInto(out *RabbitQueueMigration) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.Bindings != nil {
+		in, out := &in.Bindings, &out.Bindings
+		*out = make([]RabbitQueueMigrationBinding, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitQueueMigration.
+func (in *RabbitQueueMigration) DeepCopy() *RabbitQueueMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitQueueMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitQueueMigrationBinding) DeepCopyInto(out *RabbitQueueMigrationBinding) {
+	*out = *in
+	if in.Arguments != nil {
+		in, out := &in.Arguments, &out.Arguments
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitQueueMigrationBinding.
+func (in *RabbitQueueMigrationBinding) DeepCopy() *RabbitQueueMigrationBinding {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitQueueMigrationBinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitQueuePurge) DeepCopyInto(out *RabbitQueuePurge) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitQueuePurge.
+func (in *RabbitQueuePurge) DeepCopy() *RabbitQueuePurge {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitQueuePurge)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitQueueSpec) DeepCopyInto(out *RabbitQueueSpec) {
+	*out = *in
+	if in.Quorum != nil {
+		in, out := &in.Quorum, &out.Quorum
+		*out = new(RabbitQuorumQueue)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Stream != nil {
+		in, out := &in.Stream, &out.Stream
+		*out = new(RabbitStreamQueue)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeadLetter != nil {
+		in, out := &in.DeadLetter, &out.DeadLetter
+		*out = new(RabbitDeadLetter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutoDelete != nil {
+		in, out := &in.AutoDelete, &out.AutoDelete
+		*out = new(bool)
 		**out = **in
 	}
-	if in.InsecureSkipVerify != nil {
-		in, out := &in.InsecureSkipVerify, &out.InsecureSkipVerify
+	if in.Durable != nil {
+		in, out := &in.Durable, &out.Durable
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Arguments != nil {
+		in, out := &in.Arguments, &out.Arguments
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StatsInterval != nil {
+		in, out := &in.StatsInterval, &out.StatsInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	in.Connection.DeepCopyInto(&out.Connection)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitConnection.
-func (in *RabbitConnection) DeepCopy() *RabbitConnection {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitQueueSpec.
+func (in *RabbitQueueSpec) DeepCopy() *RabbitQueueSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(RabbitConnection)
+	out := new(RabbitQueueSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RabbitPermission) DeepCopyInto(out *RabbitPermission) {
+func (in *RabbitQueueStats) DeepCopyInto(out *RabbitQueueStats) {
 	*out = *in
+	in.LastUpdated.DeepCopyInto(&out.LastUpdated)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitPermission.
-func (in *RabbitPermission) DeepCopy() *RabbitPermission {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitQueueStats.
+func (in *RabbitQueueStats) DeepCopy() *RabbitQueueStats {
 	if in == nil {
 		return nil
 	}
-	out := new(RabbitPermission)
+	out := new(RabbitQueueStats)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RabbitPolicy) DeepCopyInto(out *RabbitPolicy) {
+func (in *RabbitQueueStatus) DeepCopyInto(out *RabbitQueueStatus) {
 	*out = *in
-	in.Definition.DeepCopyInto(&out.Definition)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]conditions.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(RabbitQueueMigration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Stats != nil {
+		in, out := &in.Stats, &out.Stats
+		*out = new(RabbitQueueStats)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastPurge != nil {
+		in, out := &in.LastPurge, &out.LastPurge
+		*out = new(RabbitQueuePurge)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitPolicy.
-func (in *RabbitPolicy) DeepCopy() *RabbitPolicy {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitQueueStatus.
+func (in *RabbitQueueStatus) DeepCopy() *RabbitQueueStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(RabbitPolicy)
+	out := new(RabbitQueueStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RabbitQueue) DeepCopyInto(out *RabbitQueue) {
+func (in *RabbitQuorumQueue) DeepCopyInto(out *RabbitQuorumQueue) {
+	*out = *in
+	if in.InitialGroupSize != nil {
+		in, out := &in.InitialGroupSize, &out.InitialGroupSize
+		*out = new(int)
+		**out = **in
+	}
+	if in.DeliveryLimit != nil {
+		in, out := &in.DeliveryLimit, &out.DeliveryLimit
+		*out = new(int)
+		**out = **in
+	}
+	if in.TargetMembers != nil {
+		in, out := &in.TargetMembers, &out.TargetMembers
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitQuorumQueue.
+func (in *RabbitQuorumQueue) DeepCopy() *RabbitQuorumQueue {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitQuorumQueue)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitShovel) DeepCopyInto(out *RabbitShovel) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -90,18 +941,18 @@ func (in *RabbitQueue) DeepCopyInto(out *RabbitQueue) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitQueue.
-func (in *RabbitQueue) DeepCopy() *RabbitQueue {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitShovel.
+func (in *RabbitShovel) DeepCopy() *RabbitShovel {
 	if in == nil {
 		return nil
 	}
-	out := new(RabbitQueue)
+	out := new(RabbitShovel)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *RabbitQueue) DeepCopyObject() runtime.Object {
+func (in *RabbitShovel) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -109,31 +960,56 @@ func (in *RabbitQueue) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RabbitQueueList) DeepCopyInto(out *RabbitQueueList) {
+func (in *RabbitShovelEndpoint) DeepCopyInto(out *RabbitShovelEndpoint) {
+	*out = *in
+	if in.Connection != nil {
+		in, out := &in.Connection, &out.Connection
+		*out = new(RabbitConnection)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UserSecretRef != nil {
+		in, out := &in.UserSecretRef, &out.UserSecretRef
+		*out = new(SecretRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitShovelEndpoint.
+func (in *RabbitShovelEndpoint) DeepCopy() *RabbitShovelEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitShovelEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitShovelList) DeepCopyInto(out *RabbitShovelList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]RabbitQueue, len(*in))
+		*out = make([]RabbitShovel, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitQueueList.
-func (in *RabbitQueueList) DeepCopy() *RabbitQueueList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitShovelList.
+func (in *RabbitShovelList) DeepCopy() *RabbitShovelList {
 	if in == nil {
 		return nil
 	}
-	out := new(RabbitQueueList)
+	out := new(RabbitShovelList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *RabbitQueueList) DeepCopyObject() runtime.Object {
+func (in *RabbitShovelList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -141,38 +1017,25 @@ func (in *RabbitQueueList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RabbitQueueSpec) DeepCopyInto(out *RabbitQueueSpec) {
+func (in *RabbitShovelSpec) DeepCopyInto(out *RabbitShovelSpec) {
 	*out = *in
-	if in.AutoDelete != nil {
-		in, out := &in.AutoDelete, &out.AutoDelete
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Durable != nil {
-		in, out := &in.Durable, &out.Durable
-		*out = new(bool)
-		**out = **in
-	}
-	if in.Arguments != nil {
-		in, out := &in.Arguments, &out.Arguments
-		*out = new(runtime.RawExtension)
-		(*in).DeepCopyInto(*out)
-	}
+	in.Source.DeepCopyInto(&out.Source)
+	in.Destination.DeepCopyInto(&out.Destination)
 	in.Connection.DeepCopyInto(&out.Connection)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitQueueSpec.
-func (in *RabbitQueueSpec) DeepCopy() *RabbitQueueSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitShovelSpec.
+func (in *RabbitShovelSpec) DeepCopy() *RabbitShovelSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(RabbitQueueSpec)
+	out := new(RabbitShovelSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RabbitQueueStatus) DeepCopyInto(out *RabbitQueueStatus) {
+func (in *RabbitShovelStatus) DeepCopyInto(out *RabbitShovelStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
@@ -183,12 +1046,47 @@ func (in *RabbitQueueStatus) DeepCopyInto(out *RabbitQueueStatus) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitQueueStatus.
-func (in *RabbitQueueStatus) DeepCopy() *RabbitQueueStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitShovelStatus.
+func (in *RabbitShovelStatus) DeepCopy() *RabbitShovelStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(RabbitQueueStatus)
+	out := new(RabbitShovelStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitStreamQueue) DeepCopyInto(out *RabbitStreamQueue) {
+	*out = *in
+	if in.MaxSegmentSize != nil {
+		in, out := &in.MaxSegmentSize, &out.MaxSegmentSize
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitStreamQueue.
+func (in *RabbitStreamQueue) DeepCopy() *RabbitStreamQueue {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitStreamQueue)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitTopicPermission) DeepCopyInto(out *RabbitTopicPermission) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitTopicPermission.
+func (in *RabbitTopicPermission) DeepCopy() *RabbitTopicPermission {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitTopicPermission)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -220,6 +1118,31 @@ func (in *RabbitUser) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitUserLimits) DeepCopyInto(out *RabbitUserLimits) {
+	*out = *in
+	if in.MaxConnections != nil {
+		in, out := &in.MaxConnections, &out.MaxConnections
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxChannels != nil {
+		in, out := &in.MaxChannels, &out.MaxChannels
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitUserLimits.
+func (in *RabbitUserLimits) DeepCopy() *RabbitUserLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitUserLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RabbitUserList) DeepCopyInto(out *RabbitUserList) {
 	*out = *in
@@ -252,14 +1175,65 @@ func (in *RabbitUserList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitUserPasswordRotation) DeepCopyInto(out *RabbitUserPasswordRotation) {
+	*out = *in
+	in.LastRotation.DeepCopyInto(&out.LastRotation)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitUserPasswordRotation.
+func (in *RabbitUserPasswordRotation) DeepCopy() *RabbitUserPasswordRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitUserPasswordRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RabbitUserSpec) DeepCopyInto(out *RabbitUserSpec) {
 	*out = *in
+	if in.TagList != nil {
+		in, out := &in.TagList, &out.TagList
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Permissions != nil {
 		in, out := &in.Permissions, &out.Permissions
 		*out = make([]RabbitPermission, len(*in))
 		copy(*out, *in)
 	}
+	if in.TopicPermissions != nil {
+		in, out := &in.TopicPermissions, &out.TopicPermissions
+		*out = make([]RabbitTopicPermission, len(*in))
+		copy(*out, *in)
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(RabbitUserLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PasswordRotation != nil {
+		in, out := &in.PasswordRotation, &out.PasswordRotation
+		*out = new(RabbitPasswordRotation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PasswordSecretRef != nil {
+		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(SecretRef)
+		**out = **in
+	}
+	if in.SecretFormats != nil {
+		in, out := &in.SecretFormats, &out.SecretFormats
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SecretTemplateRef != nil {
+		in, out := &in.SecretTemplateRef, &out.SecretTemplateRef
+		*out = new(ConfigMapRef)
+		**out = **in
+	}
 	in.Connection.DeepCopyInto(&out.Connection)
 }
 
@@ -283,6 +1257,11 @@ func (in *RabbitUserStatus) DeepCopyInto(out *RabbitUserStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PasswordRotation != nil {
+		in, out := &in.PasswordRotation, &out.PasswordRotation
+		*out = new(RabbitUserPasswordRotation)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitUserStatus.
@@ -322,6 +1301,31 @@ func (in *RabbitVhost) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitVhostLimits) DeepCopyInto(out *RabbitVhostLimits) {
+	*out = *in
+	if in.MaxConnections != nil {
+		in, out := &in.MaxConnections, &out.MaxConnections
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxQueues != nil {
+		in, out := &in.MaxQueues, &out.MaxQueues
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitVhostLimits.
+func (in *RabbitVhostLimits) DeepCopy() *RabbitVhostLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitVhostLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RabbitVhostList) DeepCopyInto(out *RabbitVhostList) {
 	*out = *in
@@ -357,6 +1361,11 @@ func (in *RabbitVhostList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RabbitVhostSpec) DeepCopyInto(out *RabbitVhostSpec) {
 	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Policies != nil {
 		in, out := &in.Policies, &out.Policies
 		*out = make(map[string]RabbitPolicy, len(*in))
@@ -364,6 +1373,18 @@ func (in *RabbitVhostSpec) DeepCopyInto(out *RabbitVhostSpec) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.OperatorPolicies != nil {
+		in, out := &in.OperatorPolicies, &out.OperatorPolicies
+		*out = make(map[string]RabbitPolicy, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(RabbitVhostLimits)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Connection.DeepCopyInto(&out.Connection)
 }
 
@@ -0,0 +1,181 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package components
+
+import (
+	"encoding/json"
+	"reflect"
+
+	cu "github.com/coderanger/controller-utils"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+type bindingComponent struct {
+	clientFactory rabbitClientFactory
+}
+
+func Binding() *bindingComponent {
+	return &bindingComponent{clientFactory: rabbitholeClientFactory}
+}
+
+func (_ *bindingComponent) GetReadyCondition() string {
+	return "BindingReady"
+}
+
+func (comp *bindingComponent) Setup(ctx *cu.Context, bldr *ctrl.Builder) error {
+	return watchClusters(ctx, bldr)
+}
+
+func (comp *bindingComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitBinding)
+	ctx.Conditions.SetUnknown("BindingReady", "Unknown")
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	// Get the core data for the binding from the object.
+	desired := rabbithole.BindingInfo{
+		Source:          obj.Spec.Source,
+		Vhost:           obj.Spec.Vhost,
+		Destination:     obj.Spec.Destination,
+		DestinationType: obj.Spec.DestinationType,
+		RoutingKey:      obj.Spec.RoutingKey,
+		Arguments:       map[string]interface{}{},
+	}
+	if obj.Spec.Arguments != nil {
+		err = json.Unmarshal(obj.Spec.Arguments.Raw, &desired.Arguments)
+		if err != nil {
+			return cu.Result{}, errors.Wrap(err, "error parsing arguments")
+		}
+	}
+
+	// Check if the binding already exists.
+	existingBinding, err := findBinding(rmqc, desired)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error getting bindings from %s to %s %s on vhost %s", desired.Source, desired.DestinationType, desired.Destination, desired.Vhost)
+	}
+
+	// Create the binding if needed.
+	if existingBinding == nil {
+		resp, err := rmqc.DeclareBinding(desired.Vhost, desired)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error creating binding from %s to %s %s on vhost %s", desired.Source, desired.DestinationType, desired.Destination, desired.Vhost)
+		}
+		if resp.StatusCode != 201 {
+			return cu.Result{}, errors.Errorf("unable to create binding from %s to %s %s on vhost %s, got response code %v", desired.Source, desired.DestinationType, desired.Destination, desired.Vhost, resp.StatusCode)
+		}
+		ctx.Events.Eventf(obj, "Normal", "BindingCreated", "RabbitMQ binding from %s to %s %s on vhost %s created", desired.Source, desired.DestinationType, desired.Destination, desired.Vhost)
+
+		// Look up the new binding to get the properties key the server assigned.
+		existingBinding, err = findBinding(rmqc, desired)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error getting bindings from %s to %s %s on vhost %s", desired.Source, desired.DestinationType, desired.Destination, desired.Vhost)
+		}
+		if existingBinding == nil {
+			return cu.Result{}, errors.Errorf("unable to find binding from %s to %s %s on vhost %s after creation", desired.Source, desired.DestinationType, desired.Destination, desired.Vhost)
+		}
+	}
+
+	// If the spec changed, the binding we created previously is stale so clean it up.
+	current := &rabbitv1beta1.RabbitBindingRef{
+		Vhost:           existingBinding.Vhost,
+		Source:          existingBinding.Source,
+		Destination:     existingBinding.Destination,
+		DestinationType: existingBinding.DestinationType,
+		PropertiesKey:   existingBinding.PropertiesKey,
+	}
+	previous := obj.Status.Binding
+	if previous != nil && *previous != *current {
+		_, err = rmqc.DeleteBinding(previous.Vhost, bindingInfoFromRef(previous))
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error deleting stale binding %s from %s to %s %s on vhost %s", previous.PropertiesKey, previous.Source, previous.DestinationType, previous.Destination, previous.Vhost)
+		}
+		ctx.Events.Eventf(obj, "Normal", "StaleBindingDeleted", "RabbitMQ binding %s from %s to %s %s on vhost %s deleted", previous.PropertiesKey, previous.Source, previous.DestinationType, previous.Destination, previous.Vhost)
+	}
+	obj.Status.Binding = current
+
+	ctx.Conditions.SetfTrue("BindingReady", "BindingExists", "RabbitMQ binding from %s to %s %s on vhost %s exists", desired.Source, desired.DestinationType, desired.Destination, desired.Vhost)
+	return cu.Result{}, nil
+}
+
+func (comp *bindingComponent) Finalize(ctx *cu.Context) (cu.Result, bool, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitBinding)
+
+	// If we never got as far as creating the binding, there is nothing to clean up.
+	if obj.Status.Binding == nil {
+		return cu.Result{}, true, nil
+	}
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, false, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	binding := obj.Status.Binding
+	_, err = rmqc.DeleteBinding(binding.Vhost, bindingInfoFromRef(binding))
+	if err != nil {
+		return cu.Result{}, false, errors.Wrapf(err, "error deleting rabbitmq binding %s from %s to %s %s on vhost %s", binding.PropertiesKey, binding.Source, binding.DestinationType, binding.Destination, binding.Vhost)
+	}
+	return cu.Result{}, true, nil
+}
+
+// Find an existing binding matching the routing key and arguments of the given binding.
+func findBinding(rmqc rabbitManager, desired rabbithole.BindingInfo) (*rabbithole.BindingInfo, error) {
+	var bindings []rabbithole.BindingInfo
+	var err error
+	if desired.DestinationType == "exchange" {
+		bindings, err = rmqc.ListExchangeBindingsBetween(desired.Vhost, desired.Source, desired.Destination)
+	} else {
+		bindings, err = rmqc.ListQueueBindingsBetween(desired.Vhost, desired.Source, desired.Destination)
+	}
+	if err != nil {
+		rabbitErr, ok := err.(rabbithole.ErrorResponse)
+		if ok && rabbitErr.StatusCode == 404 {
+			// One side of the binding doesn't exist yet, let the create call report the problem.
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	for _, binding := range bindings {
+		if binding.RoutingKey != desired.RoutingKey {
+			continue
+		}
+		// Treat missing and empty arguments as the same.
+		if len(binding.Arguments) == 0 && len(desired.Arguments) == 0 || reflect.DeepEqual(binding.Arguments, desired.Arguments) {
+			return &binding, nil
+		}
+	}
+	return nil, nil
+}
+
+func bindingInfoFromRef(ref *rabbitv1beta1.RabbitBindingRef) rabbithole.BindingInfo {
+	return rabbithole.BindingInfo{
+		Source:          ref.Source,
+		Vhost:           ref.Vhost,
+		Destination:     ref.Destination,
+		DestinationType: ref.DestinationType,
+		PropertiesKey:   ref.PropertiesKey,
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package components
+
+import (
+	cu "github.com/coderanger/controller-utils"
+	. "github.com/coderanger/controller-utils/tests/matchers"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("Binding component", func() {
+	var obj *rabbitv1beta1.RabbitBinding
+	var rabbit *fakeRabbitClient
+	var helper *cu.UnitHelper
+
+	BeforeEach(func() {
+		rabbit = newFakeRabbitClient()
+		comp := Binding()
+		comp.clientFactory = rabbit.Factory
+		obj = &rabbitv1beta1.RabbitBinding{
+			Spec: rabbitv1beta1.RabbitBindingSpec{
+				Vhost:       "/",
+				Source:      "myexchange",
+				Destination: "myqueue",
+				RoutingKey:  "mykey",
+				Connection: rabbitv1beta1.RabbitConnection{
+					Host:     "testhost",
+					Username: "testuser",
+				},
+			},
+		}
+		helper = suiteHelper.Setup(comp, obj)
+	})
+
+	It("creates a binding", func() {
+		helper.MustReconcile()
+		Expect(rabbit.Bindings).To(MatchAllKeys(Keys{
+			"/": ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
+				"Source":          Equal("myexchange"),
+				"Destination":     Equal("myqueue"),
+				"DestinationType": Equal("queue"),
+				"RoutingKey":      Equal("mykey"),
+				"PropertiesKey":   Equal("mykey"),
+			}))),
+		}))
+		Expect(obj.Status.Binding).To(Equal(&rabbitv1beta1.RabbitBindingRef{
+			Vhost:           "/",
+			Source:          "myexchange",
+			Destination:     "myqueue",
+			DestinationType: "queue",
+			PropertiesKey:   "mykey",
+		}))
+		Expect(helper.Events).To(Receive(Equal("Normal BindingCreated RabbitMQ binding from myexchange to queue myqueue on vhost / created")))
+		Expect(obj).To(HaveCondition("BindingReady").WithStatus("True").WithReason("BindingExists"))
+	})
+
+	It("does not recreate an existing binding", func() {
+		rabbit.Bindings = map[string][]*rabbithole.BindingInfo{
+			"/": {
+				{
+					Source:          "myexchange",
+					Vhost:           "/",
+					Destination:     "myqueue",
+					DestinationType: "queue",
+					RoutingKey:      "mykey",
+					PropertiesKey:   "mykey",
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Bindings["/"]).To(HaveLen(1))
+		Expect(obj.Status.Binding.PropertiesKey).To(Equal("mykey"))
+		Expect(helper.Events).ToNot(Receive())
+	})
+
+	It("creates an exchange to exchange binding with arguments", func() {
+		obj.Spec.DestinationType = "exchange"
+		obj.Spec.Destination = "otherexchange"
+		obj.Spec.Arguments = &runtime.RawExtension{
+			Raw: []byte(`{"x-match":"any"}`),
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Bindings).To(MatchAllKeys(Keys{
+			"/": ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
+				"Source":          Equal("myexchange"),
+				"Destination":     Equal("otherexchange"),
+				"DestinationType": Equal("exchange"),
+				"Arguments": MatchAllKeys(Keys{
+					"x-match": Equal("any"),
+				}),
+			}))),
+		}))
+		Expect(obj.Status.Binding.PropertiesKey).ToNot(Equal("mykey"))
+	})
+
+	It("deletes the stale binding when the spec changes", func() {
+		helper.MustReconcile()
+		Expect(helper.Events).To(Receive())
+		obj.Spec.RoutingKey = "otherkey"
+		helper.MustReconcile()
+		Expect(rabbit.Bindings).To(MatchAllKeys(Keys{
+			"/": ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
+				"RoutingKey":    Equal("otherkey"),
+				"PropertiesKey": Equal("otherkey"),
+			}))),
+		}))
+		Expect(obj.Status.Binding.PropertiesKey).To(Equal("otherkey"))
+		Expect(helper.Events).To(Receive(Equal("Normal BindingCreated RabbitMQ binding from myexchange to queue myqueue on vhost / created")))
+		Expect(helper.Events).To(Receive(Equal("Normal StaleBindingDeleted RabbitMQ binding mykey from myexchange to queue myqueue on vhost / deleted")))
+	})
+
+	It("does not touch other bindings between the same exchange and queue", func() {
+		rabbit.Bindings = map[string][]*rabbithole.BindingInfo{
+			"/": {
+				{
+					Source:          "myexchange",
+					Vhost:           "/",
+					Destination:     "myqueue",
+					DestinationType: "queue",
+					RoutingKey:      "unmanaged",
+					PropertiesKey:   "unmanaged",
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Bindings["/"]).To(HaveLen(2))
+	})
+
+	It("deletes the binding on finalize", func() {
+		helper.MustReconcile()
+		Expect(rabbit.Bindings["/"]).To(HaveLen(1))
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Bindings["/"]).To(BeEmpty())
+	})
+})
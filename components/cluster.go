@@ -0,0 +1,70 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"crypto/tls"
+	"time"
+
+	cu "github.com/coderanger/controller-utils"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+type clusterComponent struct {
+	clientFactory rabbitClientFactory
+}
+
+func Cluster() *clusterComponent {
+	return &clusterComponent{clientFactory: rabbitholeClientFactory}
+}
+
+func (_ *clusterComponent) GetReadyCondition() string {
+	return "ClusterReady"
+}
+
+func (comp *clusterComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitCluster)
+	ctx.Conditions.SetUnknown("ClusterReady", "Unknown")
+
+	// Build the connection details directly rather than via a clusterRef since we already have the object.
+	uri, rootCAs, err := clusterDefaults(ctx, obj, ctx.Client)
+	if err != nil {
+		return cu.Result{}, err
+	}
+	tlsConfig := &tls.Config{RootCAs: rootCAs}
+	if obj.Spec.TLS != nil {
+		tlsConfig.InsecureSkipVerify = obj.Spec.TLS.InsecureSkipVerify
+	}
+
+	// Being unreachable is a status to report, not a reconcile failure, so check again later either way.
+	rmqc, err := dial(uri, tlsConfig, comp.clientFactory)
+	if err != nil {
+		ctx.Conditions.SetfFalse("ClusterReady", "Unreachable", "Unable to connect to RabbitMQ cluster %s: %s", obj.Name, err)
+		return cu.Result{RequeueAfter: time.Minute}, nil
+	}
+	overview, err := rmqc.Overview()
+	if err != nil {
+		ctx.Conditions.SetfFalse("ClusterReady", "Unreachable", "Unable to connect to RabbitMQ cluster %s: %s", obj.Name, err)
+		return cu.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	obj.Status.RabbitMQVersion = overview.RabbitMQVersion
+	obj.Status.ErlangVersion = overview.ErlangVersion
+	ctx.Conditions.SetfTrue("ClusterReady", "Reachable", "RabbitMQ cluster %s is running RabbitMQ %s", obj.Name, overview.RabbitMQVersion)
+	return cu.Result{RequeueAfter: 5 * time.Minute}, nil
+}
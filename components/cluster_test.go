@@ -0,0 +1,79 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"net/http"
+
+	cu "github.com/coderanger/controller-utils"
+	. "github.com/coderanger/controller-utils/tests/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("Cluster component", func() {
+	var obj *rabbitv1beta1.RabbitCluster
+	var rabbit *fakeRabbitClient
+	var comp *clusterComponent
+	var helper *cu.UnitHelper
+
+	BeforeEach(func() {
+		rabbit = newFakeRabbitClient()
+		comp = Cluster()
+		comp.clientFactory = rabbit.Factory
+		obj = &rabbitv1beta1.RabbitCluster{
+			Spec: rabbitv1beta1.RabbitClusterSpec{
+				Host:              "testhost",
+				Username:          "testuser",
+				PasswordSecretRef: &rabbitv1beta1.ClusterSecretRef{Name: "admin", Namespace: "default"},
+			},
+		}
+		helper = suiteHelper.Setup(comp, obj)
+		helper.TestClient.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "admin"},
+			Data: map[string][]byte{
+				"password": []byte("secret"),
+			},
+		})
+	})
+
+	It("reports the broker version", func() {
+		helper.MustReconcile()
+		Expect(obj.Status.RabbitMQVersion).To(Equal("3.8.9"))
+		Expect(obj.Status.ErlangVersion).To(Equal("23.2"))
+		Expect(obj).To(HaveCondition("ClusterReady").WithStatus("True").WithReason("Reachable"))
+	})
+
+	It("reports an unreachable cluster", func() {
+		comp.clientFactory = func(_uri, _user, _pass string, _t *http.Transport) (rabbitManager, error) {
+			return nil, errors.New("connection refused")
+		}
+		helper.MustReconcile()
+		Expect(obj).To(HaveCondition("ClusterReady").WithStatus("False").WithReason("Unreachable"))
+	})
+
+	It("errors on a missing password secret", func() {
+		obj.Spec.PasswordSecretRef.Name = "other"
+		_, err := helper.Reconcile()
+		Expect(err).To(HaveOccurred())
+	})
+})
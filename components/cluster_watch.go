@@ -0,0 +1,107 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"context"
+
+	cu "github.com/coderanger/controller-utils"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+// Where the clusterRef lives in the spec of every kind with a connection.
+var clusterRefPath = []string{"spec", "connection", "clusterRef", "name"}
+
+type clusterWatchMap struct {
+	client  client.Client
+	log     logr.Logger
+	scheme  *runtime.Scheme
+	listGVK schema.GroupVersionKind
+}
+
+// Reconcile every object of the controller's kind using a RabbitCluster when it changes, so new endpoints or
+// credentials are picked up. Called from the Setup of each component that connects to RabbitMQ.
+func watchClusters(ctx *cu.Context, bldr *ctrl.Builder) error {
+	gvk, err := apiutil.GVKForObject(ctx.Object, ctx.Scheme)
+	if err != nil {
+		return errors.Wrap(err, "error finding object kind")
+	}
+	bldr.Watches(
+		&source.Kind{Type: &rabbitv1beta1.RabbitCluster{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: &clusterWatchMap{
+			client:  ctx.Client,
+			log:     ctx.Log,
+			scheme:  ctx.Scheme,
+			listGVK: gvk.GroupVersion().WithKind(gvk.Kind + "List"),
+		}},
+	)
+	return nil
+}
+
+// Watch map function used above.
+// Obj is a RabbitCluster that just got an event, map it back to any object referencing it.
+func (wm *clusterWatchMap) Map(obj handler.MapObject) []reconcile.Request {
+	requests := []reconcile.Request{}
+	list, err := wm.scheme.New(wm.listGVK)
+	if err != nil {
+		wm.log.Error(err, "error creating list", "kind", wm.listGVK.Kind)
+		return requests
+	}
+	err = wm.client.List(context.Background(), list)
+	if err != nil {
+		wm.log.Error(err, "error listing objects", "kind", wm.listGVK.Kind)
+		// TODO Metric to track this for alerting.
+		return requests
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		wm.log.Error(err, "error extracting list", "kind", wm.listGVK.Kind)
+		return requests
+	}
+	for _, item := range items {
+		fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			wm.log.Error(err, "error converting object", "kind", wm.listGVK.Kind)
+			continue
+		}
+		name, _, _ := unstructured.NestedString(fields, clusterRefPath...)
+		if name == obj.Meta.GetName() {
+			itemMeta, _ := meta.Accessor(item)
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      itemMeta.GetName(),
+					Namespace: itemMeta.GetNamespace(),
+				},
+			})
+		}
+	}
+	return requests
+}
@@ -20,6 +20,7 @@ package components
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -36,41 +37,126 @@ import (
 )
 
 type rabbitManager interface {
+	Overview() (*rabbithole.Overview, error)
+	ListNodes() ([]rabbithole.NodeInfo, error)
 	ListVhosts() ([]rabbithole.VhostInfo, error)
-	GetVhost(string) (*rabbithole.VhostInfo, error)
-	PutVhost(string, rabbithole.VhostSettings) (*http.Response, error)
+	GetVhost(string) (*vhostInfo, error)
+	PutVhost(string, vhostSettings) (*http.Response, error)
 	DeleteVhost(string) (*http.Response, error)
 	ListUsers() ([]rabbithole.UserInfo, error)
 	GetUser(string) (*rabbithole.UserInfo, error)
 	PutUser(string, rabbithole.UserSettings) (*http.Response, error)
 	DeleteUser(string) (*http.Response, error)
+	ListConnections() ([]rabbithole.ConnectionInfo, error)
 	ListPoliciesIn(vhost string) (rec []rabbithole.Policy, err error)
 	PutPolicy(vhost string, name string, policy rabbithole.Policy) (res *http.Response, err error)
 	DeletePolicy(vhost string, name string) (res *http.Response, err error)
+	ListOperatorPoliciesIn(vhost string) ([]rabbithole.Policy, error)
+	PutOperatorPolicy(vhost, name string, policy rabbithole.Policy) (*http.Response, error)
+	DeleteOperatorPolicy(vhost, name string) (*http.Response, error)
+	GetVhostLimits(vhost string) (map[string]int, error)
+	PutVhostLimit(vhost, name string, value int) (*http.Response, error)
+	DeleteVhostLimit(vhost, name string) (*http.Response, error)
 	ListPermissionsOf(username string) (rec []rabbithole.PermissionInfo, err error)
 	UpdatePermissionsIn(vhost, username string, permissions rabbithole.Permissions) (res *http.Response, err error)
 	ClearPermissionsIn(vhost, username string) (res *http.Response, err error)
+	ListTopicPermissionsOf(username string) (rec []rabbithole.TopicPermissionInfo, err error)
+	UpdateTopicPermissionsIn(vhost, username string, permissions rabbithole.TopicPermissions) (res *http.Response, err error)
+	DeleteTopicPermissionsIn(vhost, username string, exchange string) (res *http.Response, err error)
+	GetUserLimits(username string) (map[string]int, error)
+	PutUserLimit(username, name string, value int) (*http.Response, error)
+	DeleteUserLimit(username, name string) (*http.Response, error)
 	ListQueues() ([]rabbithole.QueueInfo, error)
 	ListQueuesIn(string) ([]rabbithole.QueueInfo, error)
 	GetQueue(string, string) (*rabbithole.DetailedQueueInfo, error)
 	DeclareQueue(string, string, rabbithole.QueueSettings) (*http.Response, error)
 	DeleteQueue(string, string, ...rabbithole.QueueDeleteOptions) (*http.Response, error)
+	PurgeQueue(string, string) (*http.Response, error)
+	GetQueueMembers(vhost, queue string) (*queueMembers, error)
+	AddQueueMember(vhost, queue, node string) (*http.Response, error)
+	DeleteQueueMember(vhost, queue, node string) (*http.Response, error)
+	GetExchange(string, string) (*rabbithole.DetailedExchangeInfo, error)
+	DeclareExchange(string, string, exchangeSettings) (*http.Response, error)
+	DeleteExchange(string, string) (*http.Response, error)
+	ListExchangeBindingsWithSource(vhost, exchange string) ([]rabbithole.BindingInfo, error)
+	ListExchangeBindingsWithDestination(vhost, exchange string) ([]rabbithole.BindingInfo, error)
+	ListQueueBindings(vhost, queue string) ([]rabbithole.BindingInfo, error)
+	ListQueueBindingsBetween(vhost, exchange, queue string) ([]rabbithole.BindingInfo, error)
+	ListExchangeBindingsBetween(vhost, source, destination string) ([]rabbithole.BindingInfo, error)
+	DeclareBinding(vhost string, info rabbithole.BindingInfo) (*http.Response, error)
+	DeleteBinding(vhost string, info rabbithole.BindingInfo) (*http.Response, error)
+	GetShovel(vhost, shovel string) (*rabbithole.ShovelInfo, error)
+	DeclareShovel(vhost, shovel string, info rabbithole.ShovelDefinition) (*http.Response, error)
+	DeleteShovel(vhost, shovel string) (*http.Response, error)
+	ListShovelStatusIn(vhost string) ([]shovelStatus, error)
+	GetFederationUpstream(vhost, name string) (*federationUpstream, error)
+	PutFederationUpstream(vhost, name string, definition federationDefinition) (*http.Response, error)
+	DeleteFederationUpstream(vhost, name string) (*http.Response, error)
+	ListFederationLinksIn(vhost string) ([]map[string]interface{}, error)
 }
 
 type rabbitClientFactory func(uri string, user string, pass string, t *http.Transport) (rabbitManager, error)
 
 // Implementation of rabbitMQClientFactory using rabbithole (i.e. a real client).
 func rabbitholeClientFactory(uri string, user string, pass string, t *http.Transport) (rabbitManager, error) {
-	return rabbithole.NewTLSClient(uri, user, pass, t)
+	client, err := rabbithole.NewTLSClient(uri, user, pass, t)
+	if err != nil {
+		return nil, err
+	}
+	return &rabbitholeClient{Client: client, transport: t}, nil
 }
 
 // Open a connection to the RabbitMQ server as defined by a RabbitmqConnection object.
 func connect(ctx context.Context, connection *rabbitv1beta1.RabbitConnection, namespace string, client client.Client, clientFactory rabbitClientFactory) (rabbitManager, *url.URL, error) {
+	compiledUri, tlsConfig, err := resolveConnection(ctx, connection, namespace, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	rmqc, err := dial(compiledUri, tlsConfig, clientFactory)
+	return rmqc, compiledUri, err
+}
+
+// Open a connection to the RabbitMQ server at an already resolved management API URI.
+func dial(uri *url.URL, tlsConfig *tls.Config, clientFactory rabbitClientFactory) (rabbitManager, error) {
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+	}
+
+	// TODO connection pooling? Would need to persist the Transport object.
+
+	// Connect to the rabbitmq cluster
+	user := uri.User.Username()
+	password, _ := uri.User.Password()
+	return clientFactory(uri.String(), user, password, transport)
+}
+
+// Work out the management API URI (including credentials) and TLS settings for a RabbitmqConnection object.
+func resolveConnection(ctx context.Context, connection *rabbitv1beta1.RabbitConnection, namespace string, client client.Client) (*url.URL, *tls.Config, error) {
+	// Also checked by the webhooks, but those might not be running.
+	err := rabbitv1beta1.ValidateConnection(connection)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	defaults, err := url.Parse(os.Getenv("DEFAULT_CONNECTION"))
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "unable to parse $DEFAULT_CONNECTION")
 	}
 
+	// A referenced RabbitCluster replaces the operator-wide default connection.
+	var rootCAs *x509.CertPool
+	if connection.ClusterRef != nil {
+		cluster := &rabbitv1beta1.RabbitCluster{}
+		err := client.Get(ctx, types.NamespacedName{Name: connection.ClusterRef.Name}, cluster)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error getting cluster %s", connection.ClusterRef.Name)
+		}
+		defaults, rootCAs, err = clusterDefaults(ctx, cluster, client)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	protocol := connection.Protocol
 	if protocol == "" {
 		protocol = defaults.Scheme
@@ -134,12 +220,6 @@ func connect(ctx context.Context, connection *rabbitv1beta1.RabbitConnection, na
 		insecure = false
 	}
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: insecure,
-		},
-	}
-
 	var hostAndPort string
 	if port == 0 {
 		hostAndPort = host
@@ -148,10 +228,79 @@ func connect(ctx context.Context, connection *rabbitv1beta1.RabbitConnection, na
 	}
 
 	compiledUri := &url.URL{Scheme: protocol, Host: hostAndPort, User: url.UserPassword(user, password)}
+	return compiledUri, &tls.Config{InsecureSkipVerify: insecure, RootCAs: rootCAs}, nil
+}
 
-	// TODO connection pooling? Would need to persist the Transport object.
+// Convert a RabbitCluster into a default connection URI in the same format as $DEFAULT_CONNECTION, plus any custom CAs.
+func clusterDefaults(ctx context.Context, cluster *rabbitv1beta1.RabbitCluster, c client.Client) (*url.URL, *x509.CertPool, error) {
+	var password string
+	if cluster.Spec.PasswordSecretRef != nil {
+		ref := &rabbitv1beta1.SecretRef{Name: cluster.Spec.PasswordSecretRef.Name, Key: cluster.Spec.PasswordSecretRef.Key}
+		var err error
+		password, err = getSecretValue(ctx, c, ref, cluster.Spec.PasswordSecretRef.Namespace, "password")
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error getting password for cluster %s", cluster.Name)
+		}
+	}
 
-	// Connect to the rabbitmq cluster
-	rmqc, err := clientFactory(compiledUri.String(), user, password, transport)
-	return rmqc, compiledUri, err
+	host := cluster.Spec.Host
+	if cluster.Spec.Port != 0 {
+		host = fmt.Sprintf("%s:%d", host, cluster.Spec.Port)
+	}
+	uri := &url.URL{Scheme: cluster.Spec.Protocol, Host: host, User: url.UserPassword(cluster.Spec.Username, password)}
+
+	var rootCAs *x509.CertPool
+	if cluster.Spec.TLS != nil {
+		uri.RawQuery = url.Values{"insecureSkipVerify": []string{strconv.FormatBool(cluster.Spec.TLS.InsecureSkipVerify)}}.Encode()
+		if cluster.Spec.TLS.CASecretRef != nil {
+			ref := &rabbitv1beta1.SecretRef{Name: cluster.Spec.TLS.CASecretRef.Name, Key: cluster.Spec.TLS.CASecretRef.Key}
+			caPEM, err := getSecretValue(ctx, c, ref, cluster.Spec.TLS.CASecretRef.Namespace, "ca.crt")
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "error getting CA for cluster %s", cluster.Name)
+			}
+			rootCAs = x509.NewCertPool()
+			if !rootCAs.AppendCertsFromPEM([]byte(caPEM)) {
+				return nil, nil, errors.Errorf("no certificates found in CA for cluster %s", cluster.Name)
+			}
+		}
+	}
+	return uri, rootCAs, nil
+}
+
+// Read a single value out of a Secret, using defaultKey if the reference doesn't specify one.
+func getSecretValue(ctx context.Context, c client.Client, ref *rabbitv1beta1.SecretRef, namespace string, defaultKey string) (string, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, secret)
+	if err != nil {
+		return "", errors.Wrapf(err, "error getting secret %s/%s", namespace, ref.Name)
+	}
+	key := ref.Key
+	if key == "" {
+		key = defaultKey
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", errors.Errorf("key %s not found in secret %s/%s", key, namespace, ref.Name)
+	}
+	return string(value), nil
+}
+
+// Convert a management API URI into the AMQP URI for the same server, using the default AMQP port.
+func amqpURI(uri *url.URL) *url.URL {
+	amqp := *uri
+	if amqp.Scheme == "http" {
+		amqp.Scheme = "amqp"
+	} else if amqp.Scheme == "https" {
+		amqp.Scheme = "amqps"
+	}
+	amqp.Host = amqp.Hostname()
+	return &amqp
+}
+
+// Default port for a URI from amqpURI, since the management port isn't the AMQP one.
+func amqpPort(uri *url.URL) string {
+	if uri.Scheme == "amqps" {
+		return "5671"
+	}
+	return "5672"
 }
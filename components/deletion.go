@@ -0,0 +1,32 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"fmt"
+	"time"
+
+	cu "github.com/coderanger/controller-utils"
+)
+
+// Hold off on a DeleteIfEmpty finalizer while the broker object is still in use, reporting why on the given condition.
+func deletionBlocked(ctx *cu.Context, condition string, format string, args ...interface{}) cu.Result {
+	message := fmt.Sprintf(format, args...)
+	ctx.Conditions.SetFalse(condition, "DeletionBlocked", message)
+	ctx.Events.Event(ctx.Object, "Warning", "DeletionBlocked", message)
+	return cu.Result{RequeueAfter: 30 * time.Second}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	cu "github.com/coderanger/controller-utils"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+type exchangeComponent struct {
+	clientFactory rabbitClientFactory
+}
+
+func Exchange() *exchangeComponent {
+	return &exchangeComponent{clientFactory: rabbitholeClientFactory}
+}
+
+func (_ *exchangeComponent) GetReadyCondition() string {
+	return "ExchangeReady"
+}
+
+func (comp *exchangeComponent) Setup(ctx *cu.Context, bldr *ctrl.Builder) error {
+	return watchClusters(ctx, bldr)
+}
+
+func (comp *exchangeComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitExchange)
+	ctx.Conditions.SetUnknown("ExchangeReady", "Unknown")
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	// Get the core data for the exchange from the object/context.
+	exchange := obj.Spec.ExchangeName
+	vhost := obj.Spec.Vhost
+
+	// Check if the exchange already exists.
+	var createExchange bool
+	existingExchange, err := rmqc.GetExchange(vhost, exchange)
+	if err != nil {
+		rabbitErr, ok := err.(rabbithole.ErrorResponse)
+		if ok && rabbitErr.StatusCode == 404 {
+			createExchange = true
+		} else {
+			return cu.Result{}, errors.Wrapf(err, "error getting exchange %s on vhost %s", exchange, vhost)
+		}
+	}
+
+	// If the exchange already exists, check if the spec fields match the current params. If not, flag for recreate.
+	if !createExchange {
+		validationErrors := []string{}
+		if existingExchange.Type != obj.Spec.Type {
+			validationErrors = append(validationErrors, fmt.Sprintf("Type currently %v expecting %v", existingExchange.Type, obj.Spec.Type))
+		}
+		if obj.Spec.AutoDelete != nil && existingExchange.AutoDelete != *obj.Spec.AutoDelete {
+			validationErrors = append(validationErrors, fmt.Sprintf("AutoDelete currently %v expecting %v", existingExchange.AutoDelete, *obj.Spec.AutoDelete))
+		}
+		if obj.Spec.Durable != nil && existingExchange.Durable != *obj.Spec.Durable {
+			validationErrors = append(validationErrors, fmt.Sprintf("Durable currently %v expecting %v", existingExchange.Durable, *obj.Spec.Durable))
+		}
+		if obj.Spec.Internal != nil && existingExchange.Internal != *obj.Spec.Internal {
+			validationErrors = append(validationErrors, fmt.Sprintf("Internal currently %v expecting %v", existingExchange.Internal, *obj.Spec.Internal))
+		}
+		if obj.Spec.Arguments != nil {
+			var args map[string]interface{}
+			err = json.Unmarshal(obj.Spec.Arguments.Raw, &args)
+			if err != nil {
+				return cu.Result{}, errors.Wrap(err, "error parsing arguments")
+			}
+
+			for key, val := range args {
+				existingVal, ok := existingExchange.Arguments[key]
+				if !ok {
+					validationErrors = append(validationErrors, fmt.Sprintf("Argument %s currently <not set> expecting %v", key, val))
+
+				} else if !reflect.DeepEqual(existingVal, val) {
+					validationErrors = append(validationErrors, fmt.Sprintf("Argument %s currently %v expecting %v", key, existingVal, val))
+				}
+			}
+		}
+		// Deleting an exchange silently drops all bindings to or from it, so only recreate unused exchanges.
+		if len(validationErrors) != 0 {
+			inUse, err := exchangeInUse(rmqc, vhost, exchange)
+			if err != nil {
+				return cu.Result{}, errors.Wrapf(err, "error checking bindings for exchange %s on vhost %s", exchange, vhost)
+			}
+			if inUse {
+				return cu.Result{RequeueAfter: time.Minute}, errors.Errorf("exchange settings do not match: %s", strings.Join(validationErrors, ", "))
+			}
+			_, err = rmqc.DeleteExchange(vhost, exchange)
+			if err != nil {
+				return cu.Result{}, errors.Wrapf(err, "error deleting exchange %s on vhost %s", exchange, vhost)
+			}
+			createExchange = true
+		}
+	}
+
+	// Create the exchange if needed.
+	if createExchange {
+		settings := exchangeSettings{}
+		settings.Type = obj.Spec.Type
+		if obj.Spec.AutoDelete != nil {
+			settings.AutoDelete = *obj.Spec.AutoDelete
+		}
+		if obj.Spec.Durable != nil {
+			settings.Durable = *obj.Spec.Durable
+		}
+		if obj.Spec.Internal != nil {
+			settings.Internal = *obj.Spec.Internal
+		}
+		if obj.Spec.Arguments != nil {
+			settings.Arguments = map[string]interface{}{}
+			err = json.Unmarshal(obj.Spec.Arguments.Raw, &settings.Arguments)
+			if err != nil {
+				return cu.Result{}, errors.Wrap(err, "error parsing arguments")
+			}
+		}
+		resp, err := rmqc.DeclareExchange(vhost, exchange, settings)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error creating exchange %s on vhost %s", exchange, vhost)
+		}
+		if resp.StatusCode != 201 {
+			return cu.Result{}, errors.Errorf("unable to create exchange %s on vhost %s, got response code %v", exchange, vhost, resp.StatusCode)
+		}
+		ctx.Events.Eventf(obj, "Normal", "ExchangeCreated", "RabbitMQ exchange %s on vhost %s created", exchange, vhost)
+	}
+
+	ctx.Conditions.SetfTrue("ExchangeReady", "ExchangeExists", "RabbitMQ exchange %s on vhost %s exists", exchange, vhost)
+	return cu.Result{}, nil
+}
+
+func (comp *exchangeComponent) Finalize(ctx *cu.Context) (cu.Result, bool, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitExchange)
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, false, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	_, err = rmqc.DeleteExchange(obj.Spec.Vhost, obj.Spec.ExchangeName)
+	if err != nil {
+		return cu.Result{}, false, errors.Wrapf(err, "error deleting rabbitmq exchange %s on vhost %s", obj.Spec.ExchangeName, obj.Spec.Vhost)
+	}
+	return cu.Result{}, true, nil
+}
+
+// Check if an exchange has any bindings in either direction.
+func exchangeInUse(rmqc rabbitManager, vhost, exchange string) (bool, error) {
+	bindings, err := rmqc.ListExchangeBindingsWithSource(vhost, exchange)
+	if err != nil {
+		return false, err
+	}
+	if len(bindings) != 0 {
+		return true, nil
+	}
+	bindings, err = rmqc.ListExchangeBindingsWithDestination(vhost, exchange)
+	if err != nil {
+		return false, err
+	}
+	return len(bindings) != 0, nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	cu "github.com/coderanger/controller-utils"
+	. "github.com/coderanger/controller-utils/tests/matchers"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("Exchange component", func() {
+	var obj *rabbitv1beta1.RabbitExchange
+	var rabbit *fakeRabbitClient
+	var helper *cu.UnitHelper
+
+	BeforeEach(func() {
+		rabbit = newFakeRabbitClient()
+		comp := Exchange()
+		comp.clientFactory = rabbit.Factory
+		obj = &rabbitv1beta1.RabbitExchange{
+			Spec: rabbitv1beta1.RabbitExchangeSpec{
+				Vhost: "/",
+				Connection: rabbitv1beta1.RabbitConnection{
+					Host:     "testhost",
+					Username: "testuser",
+				},
+			},
+		}
+		helper = suiteHelper.Setup(comp, obj)
+	})
+
+	It("creates an exchange", func() {
+		helper.MustReconcile()
+		Expect(rabbit.Exchanges).To(MatchAllKeys(Keys{
+			"/": MatchAllKeys(Keys{
+				"testing": PointTo(MatchFields(IgnoreExtras, Fields{
+					"Name": Equal("testing"),
+					"Type": Equal("direct"),
+				})),
+			}),
+		}))
+		Expect(helper.Events).To(Receive(Equal("Normal ExchangeCreated RabbitMQ exchange testing on vhost / created")))
+		Expect(obj).To(HaveCondition("ExchangeReady").WithStatus("True").WithReason("ExchangeExists"))
+	})
+
+	It("does not update an existing exchange", func() {
+		rabbit.Exchanges = map[string]map[string]*rabbithole.ExchangeInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+					Type:  "direct",
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(helper.Events).ToNot(Receive())
+	})
+
+	It("sets exchange parameters", func() {
+		t := true
+		obj.Spec.Type = "topic"
+		obj.Spec.Durable = &t
+		obj.Spec.Internal = &t
+		obj.Spec.Arguments = &runtime.RawExtension{
+			Raw: []byte(`{"alternate-exchange":"other"}`),
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Exchanges).To(MatchAllKeys(Keys{
+			"/": MatchAllKeys(Keys{
+				"testing": PointTo(MatchFields(IgnoreExtras, Fields{
+					"Name":     Equal("testing"),
+					"Type":     Equal("topic"),
+					"Durable":  BeTrue(),
+					"Internal": BeTrue(),
+					"Arguments": MatchAllKeys(Keys{
+						"alternate-exchange": Equal("other"),
+					}),
+				})),
+			}),
+		}))
+	})
+
+	It("recreates on mismatched exchange parameters", func() {
+		obj.Spec.Type = "fanout"
+		rabbit.Exchanges = map[string]map[string]*rabbithole.ExchangeInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+					Type:  "direct",
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Exchanges).To(MatchAllKeys(Keys{
+			"/": MatchAllKeys(Keys{
+				"testing": PointTo(MatchFields(IgnoreExtras, Fields{
+					"Name": Equal("testing"),
+					"Type": Equal("fanout"),
+				})),
+			}),
+		}))
+		Expect(helper.Events).To(Receive(Equal("Normal ExchangeCreated RabbitMQ exchange testing on vhost / created")))
+	})
+
+	It("does not recreate a mismatched exchange with bindings", func() {
+		obj.Spec.Type = "fanout"
+		rabbit.Exchanges = map[string]map[string]*rabbithole.ExchangeInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+					Type:  "direct",
+				},
+			},
+		}
+		rabbit.Bindings = map[string][]*rabbithole.BindingInfo{
+			"/": {
+				{
+					Source:          "testing",
+					Vhost:           "/",
+					Destination:     "myqueue",
+					DestinationType: "queue",
+				},
+			},
+		}
+		_, err := helper.Reconcile()
+		Expect(err).To(MatchError("exchange settings do not match: Type currently direct expecting fanout"))
+		Expect(rabbit.Exchanges["/"]["testing"].Type).To(Equal("direct"))
+	})
+
+	It("deletes the exchange on finalize", func() {
+		rabbit.Exchanges = map[string]map[string]*rabbithole.ExchangeInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+					Type:  "direct",
+				},
+			},
+		}
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Exchanges["/"]).To(BeEmpty())
+	})
+})
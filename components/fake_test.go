@@ -18,31 +18,74 @@ limitations under the License.
 package components
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"net/http"
+	"strings"
 
 	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
 )
 
 type fakeRabbitClient struct {
-	Users  []*rabbithole.UserInfo
-	Vhosts []*rabbithole.VhostInfo
+	Users       []*rabbithole.UserInfo
+	Vhosts      []*vhostInfo
+	Connections []*rabbithole.ConnectionInfo
 	// [vhost][policyName]
 	Policies map[string]map[string]*rabbithole.Policy
+	// [vhost][policyName]
+	OperatorPolicies map[string]map[string]*rabbithole.Policy
+	// [vhost][limitName]
+	VhostLimits map[string]map[string]int
+	// [username][limitName]
+	UserLimits map[string]map[string]int
 	// [username][vhost]
 	Permissions map[string]map[string]*rabbithole.PermissionInfo
+	// [username][vhost][exchange]
+	TopicPermissions map[string]map[string]map[string]*rabbithole.TopicPermissionInfo
 	// [vhost][queue]
 	Queues map[string]map[string]*rabbithole.QueueInfo
+	// [vhost][exchange]
+	Exchanges map[string]map[string]*rabbithole.ExchangeInfo
+	// [vhost]
+	Bindings map[string][]*rabbithole.BindingInfo
+	// [vhost][shovel]
+	Shovels map[string]map[string]*rabbithole.ShovelInfo
+	// [vhost][shovel]
+	ShovelStatuses map[string]map[string]*shovelStatus
+	// [vhost][upstream]
+	FederationUpstreams map[string]map[string]*federationUpstream
+	// [vhost]
+	FederationLinks map[string][]map[string]interface{}
+	// [vhost][queue]
+	QueueMembers map[string]map[string]*queueMembers
+	Nodes        []rabbithole.NodeInfo
+	// Reported by Overview.
+	Version string
 }
 
 var _ rabbitManager = &fakeRabbitClient{}
 
 func newFakeRabbitClient() *fakeRabbitClient {
 	return &fakeRabbitClient{
-		Users:       []*rabbithole.UserInfo{},
-		Vhosts:      []*rabbithole.VhostInfo{},
-		Policies:    map[string]map[string]*rabbithole.Policy{},
-		Permissions: map[string]map[string]*rabbithole.PermissionInfo{},
-		Queues:      map[string]map[string]*rabbithole.QueueInfo{},
+		Users:               []*rabbithole.UserInfo{},
+		Vhosts:              []*vhostInfo{},
+		Connections:         []*rabbithole.ConnectionInfo{},
+		Policies:            map[string]map[string]*rabbithole.Policy{},
+		OperatorPolicies:    map[string]map[string]*rabbithole.Policy{},
+		VhostLimits:         map[string]map[string]int{},
+		UserLimits:          map[string]map[string]int{},
+		Permissions:         map[string]map[string]*rabbithole.PermissionInfo{},
+		TopicPermissions:    map[string]map[string]map[string]*rabbithole.TopicPermissionInfo{},
+		Queues:              map[string]map[string]*rabbithole.QueueInfo{},
+		Exchanges:           map[string]map[string]*rabbithole.ExchangeInfo{},
+		Bindings:            map[string][]*rabbithole.BindingInfo{},
+		Shovels:             map[string]map[string]*rabbithole.ShovelInfo{},
+		ShovelStatuses:      map[string]map[string]*shovelStatus{},
+		FederationUpstreams: map[string]map[string]*federationUpstream{},
+		FederationLinks:     map[string][]map[string]interface{}{},
+		QueueMembers:        map[string]map[string]*queueMembers{},
+		Nodes:               []rabbithole.NodeInfo{},
+		Version:             "3.8.9",
 	}
 }
 
@@ -50,6 +93,14 @@ func (frc *fakeRabbitClient) Factory(_uri, _user, _pass string, _t *http.Transpo
 	return frc, nil
 }
 
+func (frc *fakeRabbitClient) Overview() (*rabbithole.Overview, error) {
+	return &rabbithole.Overview{RabbitMQVersion: frc.Version, ErlangVersion: "23.2", ManagementVersion: frc.Version}, nil
+}
+
+func (frc *fakeRabbitClient) ListNodes() ([]rabbithole.NodeInfo, error) {
+	return frc.Nodes, nil
+}
+
 func (frc *fakeRabbitClient) ListUsers() ([]rabbithole.UserInfo, error) {
 	users := []rabbithole.UserInfo{}
 	for _, user := range frc.Users {
@@ -94,12 +145,20 @@ func (frc *fakeRabbitClient) DeleteUser(name string) (*http.Response, error) {
 func (frc *fakeRabbitClient) ListVhosts() ([]rabbithole.VhostInfo, error) {
 	vhosts := []rabbithole.VhostInfo{}
 	for _, vhost := range frc.Vhosts {
-		vhosts = append(vhosts, *vhost)
+		vhosts = append(vhosts, rabbithole.VhostInfo{Name: vhost.Name, Tracing: vhost.Tracing})
 	}
 	return vhosts, nil
 }
 
-func (frc *fakeRabbitClient) GetVhost(name string) (*rabbithole.VhostInfo, error) {
+func (frc *fakeRabbitClient) ListConnections() ([]rabbithole.ConnectionInfo, error) {
+	connections := []rabbithole.ConnectionInfo{}
+	for _, conn := range frc.Connections {
+		connections = append(connections, *conn)
+	}
+	return connections, nil
+}
+
+func (frc *fakeRabbitClient) GetVhost(name string) (*vhostInfo, error) {
 	for _, vhost := range frc.Vhosts {
 		if vhost.Name == name {
 			return vhost, nil
@@ -108,13 +167,18 @@ func (frc *fakeRabbitClient) GetVhost(name string) (*rabbithole.VhostInfo, error
 	return nil, rabbithole.ErrorResponse{StatusCode: 404}
 }
 
-func (frc *fakeRabbitClient) PutVhost(vhost string, _settings rabbithole.VhostSettings) (*http.Response, error) {
-	for _, element := range frc.Vhosts {
+func (frc *fakeRabbitClient) PutVhost(vhost string, settings vhostSettings) (*http.Response, error) {
+	info := &vhostInfo{Name: vhost, Description: settings.Description, DefaultQueueType: settings.DefaultQueueType, Tracing: settings.Tracing}
+	if settings.Tags != "" {
+		info.Tags = strings.Split(settings.Tags, ",")
+	}
+	for i, element := range frc.Vhosts {
 		if element.Name == vhost {
-			return &http.Response{StatusCode: 200}, nil
+			frc.Vhosts[i] = info
+			return &http.Response{StatusCode: 204}, nil
 		}
 	}
-	frc.Vhosts = append(frc.Vhosts, &rabbithole.VhostInfo{Name: vhost})
+	frc.Vhosts = append(frc.Vhosts, info)
 	return &http.Response{StatusCode: 201}, nil
 }
 
@@ -123,6 +187,8 @@ func (frc *fakeRabbitClient) DeleteVhost(vhost string) (*http.Response, error) {
 		if element.Name == vhost {
 			copy(frc.Vhosts[i:], frc.Vhosts[i+1:])
 			frc.Vhosts = frc.Vhosts[:len(frc.Vhosts)-1]
+			// Queues go with their vhost.
+			delete(frc.Queues, vhost)
 			return &http.Response{StatusCode: 204}, nil
 		}
 	}
@@ -165,6 +231,79 @@ func (frc *fakeRabbitClient) DeletePolicy(vhost string, name string) (res *http.
 	return &http.Response{StatusCode: 204}, nil
 }
 
+func (frc *fakeRabbitClient) ListOperatorPoliciesIn(vhost string) ([]rabbithole.Policy, error) {
+	policies := []rabbithole.Policy{}
+	for _, policy := range frc.OperatorPolicies[vhost] {
+		policies = append(policies, *policy)
+	}
+	return policies, nil
+}
+
+func (frc *fakeRabbitClient) PutOperatorPolicy(vhost, name string, policy rabbithole.Policy) (*http.Response, error) {
+	vhostPolicies, ok := frc.OperatorPolicies[vhost]
+	if !ok {
+		vhostPolicies = map[string]*rabbithole.Policy{}
+		frc.OperatorPolicies[vhost] = vhostPolicies
+	}
+	_, ok = vhostPolicies[name]
+	vhostPolicies[name] = &policy
+	if ok {
+		return &http.Response{StatusCode: 204}, nil
+	}
+	return &http.Response{StatusCode: 201}, nil
+}
+
+func (frc *fakeRabbitClient) DeleteOperatorPolicy(vhost, name string) (*http.Response, error) {
+	delete(frc.OperatorPolicies[vhost], name)
+	return &http.Response{StatusCode: 204}, nil
+}
+
+func (frc *fakeRabbitClient) GetVhostLimits(vhost string) (map[string]int, error) {
+	limits := map[string]int{}
+	for name, value := range frc.VhostLimits[vhost] {
+		limits[name] = value
+	}
+	return limits, nil
+}
+
+func (frc *fakeRabbitClient) PutVhostLimit(vhost, name string, value int) (*http.Response, error) {
+	vhostLimits, ok := frc.VhostLimits[vhost]
+	if !ok {
+		vhostLimits = map[string]int{}
+		frc.VhostLimits[vhost] = vhostLimits
+	}
+	vhostLimits[name] = value
+	return &http.Response{StatusCode: 204}, nil
+}
+
+func (frc *fakeRabbitClient) DeleteVhostLimit(vhost, name string) (*http.Response, error) {
+	delete(frc.VhostLimits[vhost], name)
+	return &http.Response{StatusCode: 204}, nil
+}
+
+func (frc *fakeRabbitClient) GetUserLimits(username string) (map[string]int, error) {
+	limits := map[string]int{}
+	for name, value := range frc.UserLimits[username] {
+		limits[name] = value
+	}
+	return limits, nil
+}
+
+func (frc *fakeRabbitClient) PutUserLimit(username, name string, value int) (*http.Response, error) {
+	userLimits, ok := frc.UserLimits[username]
+	if !ok {
+		userLimits = map[string]int{}
+		frc.UserLimits[username] = userLimits
+	}
+	userLimits[name] = value
+	return &http.Response{StatusCode: 204}, nil
+}
+
+func (frc *fakeRabbitClient) DeleteUserLimit(username, name string) (*http.Response, error) {
+	delete(frc.UserLimits[username], name)
+	return &http.Response{StatusCode: 204}, nil
+}
+
 func (frc *fakeRabbitClient) ListPermissionsOf(username string) (rec []rabbithole.PermissionInfo, err error) {
 	userPerms, ok := frc.Permissions[username]
 	if !ok {
@@ -214,6 +353,56 @@ func (frc *fakeRabbitClient) ClearPermissionsIn(vhost, username string) (res *ht
 	return &http.Response{StatusCode: 204}, nil
 }
 
+func (frc *fakeRabbitClient) ListTopicPermissionsOf(username string) (rec []rabbithole.TopicPermissionInfo, err error) {
+	perms := []rabbithole.TopicPermissionInfo{}
+	for _, vhostPerms := range frc.TopicPermissions[username] {
+		for _, perm := range vhostPerms {
+			perms = append(perms, *perm)
+		}
+	}
+	return perms, nil
+}
+
+func (frc *fakeRabbitClient) UpdateTopicPermissionsIn(vhost, username string, permissions rabbithole.TopicPermissions) (res *http.Response, err error) {
+	userPerms, ok := frc.TopicPermissions[username]
+	if !ok {
+		userPerms = map[string]map[string]*rabbithole.TopicPermissionInfo{}
+		frc.TopicPermissions[username] = userPerms
+	}
+	vhostPerms, ok := userPerms[vhost]
+	if !ok {
+		vhostPerms = map[string]*rabbithole.TopicPermissionInfo{}
+		userPerms[vhost] = vhostPerms
+	}
+
+	_, ok = vhostPerms[permissions.Exchange]
+	vhostPerms[permissions.Exchange] = &rabbithole.TopicPermissionInfo{
+		User:     username,
+		Vhost:    vhost,
+		Exchange: permissions.Exchange,
+		Write:    permissions.Write,
+		Read:     permissions.Read,
+	}
+	if ok {
+		return &http.Response{StatusCode: 204}, nil
+	}
+	return &http.Response{StatusCode: 201}, nil
+}
+
+func (frc *fakeRabbitClient) DeleteTopicPermissionsIn(vhost, username string, exchange string) (res *http.Response, err error) {
+	vhostPerms, ok := frc.TopicPermissions[username][vhost]
+	if ok {
+		delete(vhostPerms, exchange)
+		if len(vhostPerms) == 0 {
+			delete(frc.TopicPermissions[username], vhost)
+		}
+		if len(frc.TopicPermissions[username]) == 0 {
+			delete(frc.TopicPermissions, username)
+		}
+	}
+	return &http.Response{StatusCode: 204}, nil
+}
+
 func (frc *fakeRabbitClient) ListQueues() ([]rabbithole.QueueInfo, error) {
 	queues := []rabbithole.QueueInfo{}
 	for _, vhost := range frc.Queues {
@@ -250,14 +439,8 @@ func (frc *fakeRabbitClient) GetQueue(vhost string, queue string) (*rabbithole.D
 		// What does this actually return in real life?
 		return nil, rabbithole.ErrorResponse{StatusCode: 404}
 	}
-	detailedInfo := &rabbithole.DetailedQueueInfo{
-		Name:       queueInfo.Name,
-		Vhost:      queueInfo.Vhost,
-		Durable:    queueInfo.Durable,
-		AutoDelete: queueInfo.AutoDelete,
-		Arguments:  queueInfo.Arguments,
-	}
-	return detailedInfo, nil
+	detailedInfo := rabbithole.DetailedQueueInfo(*queueInfo)
+	return &detailedInfo, nil
 }
 
 func (frc *fakeRabbitClient) DeclareQueue(vhost, queue string, info rabbithole.QueueSettings) (*http.Response, error) {
@@ -281,12 +464,289 @@ func (frc *fakeRabbitClient) DeclareQueue(vhost, queue string, info rabbithole.Q
 	}
 }
 
-func (frc *fakeRabbitClient) DeleteQueue(vhost, queue string, _ ...rabbithole.QueueDeleteOptions) (*http.Response, error) {
+func (frc *fakeRabbitClient) PurgeQueue(vhost, queue string) (*http.Response, error) {
+	queueInfo, ok := frc.Queues[vhost][queue]
+	if !ok {
+		return nil, rabbithole.ErrorResponse{StatusCode: 404}
+	}
+	queueInfo.Messages = 0
+	queueInfo.MessagesReady = 0
+	return &http.Response{StatusCode: 204}, nil
+}
+
+func (frc *fakeRabbitClient) GetQueueMembers(vhost, queue string) (*queueMembers, error) {
+	members, ok := frc.QueueMembers[vhost][queue]
+	if !ok {
+		return nil, rabbithole.ErrorResponse{StatusCode: 404}
+	}
+	return members, nil
+}
+
+func (frc *fakeRabbitClient) AddQueueMember(vhost, queue, node string) (*http.Response, error) {
+	members, ok := frc.QueueMembers[vhost][queue]
+	if !ok {
+		return nil, rabbithole.ErrorResponse{StatusCode: 404}
+	}
+	members.Members = append(members.Members, node)
+	members.Online = append(members.Online, node)
+	return &http.Response{StatusCode: 204}, nil
+}
+
+func (frc *fakeRabbitClient) DeleteQueueMember(vhost, queue, node string) (*http.Response, error) {
+	members, ok := frc.QueueMembers[vhost][queue]
+	if !ok {
+		return nil, rabbithole.ErrorResponse{StatusCode: 404}
+	}
+	remove := func(nodes []string) []string {
+		kept := []string{}
+		for _, n := range nodes {
+			if n != node {
+				kept = append(kept, n)
+			}
+		}
+		return kept
+	}
+	members.Members = remove(members.Members)
+	members.Online = remove(members.Online)
+	return &http.Response{StatusCode: 204}, nil
+}
+
+func (frc *fakeRabbitClient) DeleteQueue(vhost, queue string, opts ...rabbithole.QueueDeleteOptions) (*http.Response, error) {
 	vhostQueues, ok := frc.Queues[vhost]
 	if !ok {
 		return &http.Response{StatusCode: 404}, nil
 	}
+	queueInfo, ok := vhostQueues[queue]
+	if ok && len(opts) != 0 && opts[0].IfEmpty && queueInfo.Messages != 0 {
+		return nil, rabbithole.ErrorResponse{StatusCode: 400, Message: "bad_request", Reason: "queue not empty"}
+	}
 	delete(vhostQueues, queue)
+	// Bindings go with the queue.
+	kept := []*rabbithole.BindingInfo{}
+	for _, binding := range frc.Bindings[vhost] {
+		if binding.DestinationType != "queue" || binding.Destination != queue {
+			kept = append(kept, binding)
+		}
+	}
+	frc.Bindings[vhost] = kept
 	// What does this actually return in real life?
 	return &http.Response{StatusCode: 204}, nil
 }
+
+func (frc *fakeRabbitClient) GetExchange(vhost, exchange string) (*rabbithole.DetailedExchangeInfo, error) {
+	vhostExchanges, ok := frc.Exchanges[vhost]
+	if !ok {
+		return nil, rabbithole.ErrorResponse{StatusCode: 404}
+	}
+	exchangeInfo, ok := vhostExchanges[exchange]
+	if !ok {
+		return nil, rabbithole.ErrorResponse{StatusCode: 404}
+	}
+	detailedInfo := &rabbithole.DetailedExchangeInfo{
+		Name:       exchangeInfo.Name,
+		Vhost:      exchangeInfo.Vhost,
+		Type:       exchangeInfo.Type,
+		Durable:    exchangeInfo.Durable,
+		AutoDelete: exchangeInfo.AutoDelete,
+		Internal:   exchangeInfo.Internal,
+		Arguments:  exchangeInfo.Arguments,
+	}
+	return detailedInfo, nil
+}
+
+func (frc *fakeRabbitClient) DeclareExchange(vhost, exchange string, settings exchangeSettings) (*http.Response, error) {
+	vhostExchanges, ok := frc.Exchanges[vhost]
+	if !ok {
+		vhostExchanges = map[string]*rabbithole.ExchangeInfo{}
+		frc.Exchanges[vhost] = vhostExchanges
+	}
+	_, ok = vhostExchanges[exchange]
+	vhostExchanges[exchange] = &rabbithole.ExchangeInfo{
+		Name:       exchange,
+		Vhost:      vhost,
+		Type:       settings.Type,
+		Durable:    settings.Durable,
+		AutoDelete: settings.AutoDelete,
+		Internal:   settings.Internal,
+		Arguments:  settings.Arguments,
+	}
+	if ok {
+		return &http.Response{StatusCode: 204}, nil
+	} else {
+		return &http.Response{StatusCode: 201}, nil
+	}
+}
+
+func (frc *fakeRabbitClient) DeleteExchange(vhost, exchange string) (*http.Response, error) {
+	vhostExchanges, ok := frc.Exchanges[vhost]
+	if !ok {
+		return &http.Response{StatusCode: 404}, nil
+	}
+	delete(vhostExchanges, exchange)
+	return &http.Response{StatusCode: 204}, nil
+}
+
+func (frc *fakeRabbitClient) ListExchangeBindingsWithSource(vhost, exchange string) ([]rabbithole.BindingInfo, error) {
+	bindings := []rabbithole.BindingInfo{}
+	for _, binding := range frc.Bindings[vhost] {
+		if binding.Source == exchange {
+			bindings = append(bindings, *binding)
+		}
+	}
+	return bindings, nil
+}
+
+func (frc *fakeRabbitClient) ListExchangeBindingsWithDestination(vhost, exchange string) ([]rabbithole.BindingInfo, error) {
+	bindings := []rabbithole.BindingInfo{}
+	for _, binding := range frc.Bindings[vhost] {
+		if binding.DestinationType == "exchange" && binding.Destination == exchange {
+			bindings = append(bindings, *binding)
+		}
+	}
+	return bindings, nil
+}
+
+func (frc *fakeRabbitClient) ListQueueBindings(vhost, queue string) ([]rabbithole.BindingInfo, error) {
+	bindings := []rabbithole.BindingInfo{}
+	for _, binding := range frc.Bindings[vhost] {
+		if binding.DestinationType == "queue" && binding.Destination == queue {
+			bindings = append(bindings, *binding)
+		}
+	}
+	return bindings, nil
+}
+
+func (frc *fakeRabbitClient) ListQueueBindingsBetween(vhost, exchange, queue string) ([]rabbithole.BindingInfo, error) {
+	return frc.listBindingsBetween(vhost, exchange, "queue", queue), nil
+}
+
+func (frc *fakeRabbitClient) ListExchangeBindingsBetween(vhost, source, destination string) ([]rabbithole.BindingInfo, error) {
+	return frc.listBindingsBetween(vhost, source, "exchange", destination), nil
+}
+
+func (frc *fakeRabbitClient) listBindingsBetween(vhost, source, destinationType, destination string) []rabbithole.BindingInfo {
+	bindings := []rabbithole.BindingInfo{}
+	for _, binding := range frc.Bindings[vhost] {
+		if binding.Source == source && binding.DestinationType == destinationType && binding.Destination == destination {
+			bindings = append(bindings, *binding)
+		}
+	}
+	return bindings
+}
+
+func (frc *fakeRabbitClient) DeclareBinding(vhost string, info rabbithole.BindingInfo) (*http.Response, error) {
+	info.Vhost = vhost
+	// Not the same algorithm as RabbitMQ but close enough to be unique.
+	info.PropertiesKey = info.RoutingKey
+	if len(info.Arguments) != 0 {
+		args, err := json.Marshal(info.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		info.PropertiesKey += "~" + base64.RawURLEncoding.EncodeToString(args)
+	} else if info.PropertiesKey == "" {
+		info.PropertiesKey = "~"
+	}
+	for _, binding := range frc.Bindings[vhost] {
+		if binding.Source == info.Source && binding.DestinationType == info.DestinationType && binding.Destination == info.Destination && binding.PropertiesKey == info.PropertiesKey {
+			return &http.Response{StatusCode: 201}, nil
+		}
+	}
+	frc.Bindings[vhost] = append(frc.Bindings[vhost], &info)
+	return &http.Response{StatusCode: 201}, nil
+}
+
+func (frc *fakeRabbitClient) DeleteBinding(vhost string, info rabbithole.BindingInfo) (*http.Response, error) {
+	bindings := frc.Bindings[vhost]
+	for i, binding := range bindings {
+		if binding.Source == info.Source && binding.DestinationType == info.DestinationType && binding.Destination == info.Destination && binding.PropertiesKey == info.PropertiesKey {
+			copy(bindings[i:], bindings[i+1:])
+			frc.Bindings[vhost] = bindings[:len(bindings)-1]
+			return &http.Response{StatusCode: 204}, nil
+		}
+	}
+	return &http.Response{StatusCode: 404}, nil
+}
+
+func (frc *fakeRabbitClient) GetShovel(vhost, shovel string) (*rabbithole.ShovelInfo, error) {
+	shovelInfo, ok := frc.Shovels[vhost][shovel]
+	if !ok {
+		return nil, rabbithole.ErrorResponse{StatusCode: 404}
+	}
+	return shovelInfo, nil
+}
+
+func (frc *fakeRabbitClient) DeclareShovel(vhost, shovel string, info rabbithole.ShovelDefinition) (*http.Response, error) {
+	vhostShovels, ok := frc.Shovels[vhost]
+	if !ok {
+		vhostShovels = map[string]*rabbithole.ShovelInfo{}
+		frc.Shovels[vhost] = vhostShovels
+	}
+	_, ok = vhostShovels[shovel]
+	vhostShovels[shovel] = &rabbithole.ShovelInfo{
+		Name:       shovel,
+		Vhost:      vhost,
+		Component:  "shovel",
+		Definition: info,
+	}
+	// New shovels start out starting, like the real thing.
+	vhostStatuses, statusOk := frc.ShovelStatuses[vhost]
+	if !statusOk {
+		vhostStatuses = map[string]*shovelStatus{}
+		frc.ShovelStatuses[vhost] = vhostStatuses
+	}
+	if _, statusOk = vhostStatuses[shovel]; !statusOk {
+		vhostStatuses[shovel] = &shovelStatus{Name: shovel, Vhost: vhost, Type: "dynamic", State: "starting"}
+	}
+	if ok {
+		return &http.Response{StatusCode: 204}, nil
+	}
+	return &http.Response{StatusCode: 201}, nil
+}
+
+func (frc *fakeRabbitClient) DeleteShovel(vhost, shovel string) (*http.Response, error) {
+	delete(frc.Shovels[vhost], shovel)
+	delete(frc.ShovelStatuses[vhost], shovel)
+	return &http.Response{StatusCode: 204}, nil
+}
+
+func (frc *fakeRabbitClient) ListShovelStatusIn(vhost string) ([]shovelStatus, error) {
+	statuses := []shovelStatus{}
+	for _, status := range frc.ShovelStatuses[vhost] {
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+func (frc *fakeRabbitClient) GetFederationUpstream(vhost, name string) (*federationUpstream, error) {
+	upstream, ok := frc.FederationUpstreams[vhost][name]
+	if !ok {
+		return nil, rabbithole.ErrorResponse{StatusCode: 404}
+	}
+	return upstream, nil
+}
+
+func (frc *fakeRabbitClient) PutFederationUpstream(vhost, name string, definition federationDefinition) (*http.Response, error) {
+	vhostUpstreams, ok := frc.FederationUpstreams[vhost]
+	if !ok {
+		vhostUpstreams = map[string]*federationUpstream{}
+		frc.FederationUpstreams[vhost] = vhostUpstreams
+	}
+	_, ok = vhostUpstreams[name]
+	vhostUpstreams[name] = &federationUpstream{Name: name, Vhost: vhost, Definition: definition}
+	if ok {
+		return &http.Response{StatusCode: 204}, nil
+	}
+	return &http.Response{StatusCode: 201}, nil
+}
+
+func (frc *fakeRabbitClient) DeleteFederationUpstream(vhost, name string) (*http.Response, error) {
+	delete(frc.FederationUpstreams[vhost], name)
+	return &http.Response{StatusCode: 204}, nil
+}
+
+func (frc *fakeRabbitClient) ListFederationLinksIn(vhost string) ([]map[string]interface{}, error) {
+	links := []map[string]interface{}{}
+	links = append(links, frc.FederationLinks[vhost]...)
+	return links, nil
+}
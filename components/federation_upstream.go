@@ -0,0 +1,222 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package components
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	cu "github.com/coderanger/controller-utils"
+	"github.com/go-logr/logr"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+type federationUpstreamComponent struct {
+	clientFactory rabbitClientFactory
+}
+
+type federationUpstreamComponentWatchMap struct {
+	client client.Client
+	log    logr.Logger
+}
+
+func FederationUpstream() *federationUpstreamComponent {
+	return &federationUpstreamComponent{clientFactory: rabbitholeClientFactory}
+}
+
+func (_ *federationUpstreamComponent) GetReadyCondition() string {
+	return "FederationUpstreamReady"
+}
+
+func (comp *federationUpstreamComponent) Setup(ctx *cu.Context, bldr *ctrl.Builder) error {
+	bldr.Watches(
+		&source.Kind{Type: &corev1.Secret{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: &federationUpstreamComponentWatchMap{client: ctx.Client, log: ctx.Log}},
+	)
+	return watchClusters(ctx, bldr)
+}
+
+// Watch map function used above.
+// Obj is a Secret that just got an event, map it back to any upstream in the same namespace taking its URI from it.
+func (wm *federationUpstreamComponentWatchMap) Map(obj handler.MapObject) []reconcile.Request {
+	requests := []reconcile.Request{}
+	upstreams := &rabbitv1beta1.RabbitFederationUpstreamList{}
+	err := wm.client.List(context.Background(), upstreams, client.InNamespace(obj.Meta.GetNamespace()))
+	if err != nil {
+		wm.log.Error(err, "error listing federation upstreams")
+		// TODO Metric to track this for alerting.
+		return requests
+	}
+	for _, upstream := range upstreams.Items {
+		if upstream.Spec.URISecretRef.Name == obj.Meta.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      upstream.Name,
+					Namespace: upstream.Namespace,
+				},
+			})
+		}
+	}
+	return requests
+}
+
+func (comp *federationUpstreamComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitFederationUpstream)
+	ctx.Conditions.SetUnknown("FederationUpstreamReady", "Unknown")
+	ctx.Conditions.SetUnknown("FederationLinksReady", "Unknown")
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	// Get the core data for the upstream from the object.
+	upstream := obj.Spec.UpstreamName
+	vhost := obj.Spec.Vhost
+	uri, err := getSecretValue(ctx, ctx.Client, &obj.Spec.URISecretRef, obj.Namespace, "RABBIT_URL")
+	if err != nil {
+		return cu.Result{}, errors.Wrap(err, "error getting upstream URI")
+	}
+
+	definition := federationDefinition{
+		Uri:            uri,
+		Expires:        obj.Spec.Expires,
+		MessageTTL:     obj.Spec.MessageTTL,
+		MaxHops:        obj.Spec.MaxHops,
+		PrefetchCount:  obj.Spec.PrefetchCount,
+		ReconnectDelay: obj.Spec.ReconnectDelay,
+		AckMode:        obj.Spec.AckMode,
+		TrustUserId:    obj.Spec.TrustUserID,
+	}
+
+	// Check if the upstream already exists and matches.
+	var createUpstream, updateUpstream bool
+	existingUpstream, err := rmqc.GetFederationUpstream(vhost, upstream)
+	if err != nil {
+		rabbitErr, ok := err.(rabbithole.ErrorResponse)
+		if ok && rabbitErr.StatusCode == 404 {
+			createUpstream = true
+		} else {
+			return cu.Result{}, errors.Wrapf(err, "error getting federation upstream %s on vhost %s", upstream, vhost)
+		}
+	} else {
+		updateUpstream = !reflect.DeepEqual(existingUpstream.Definition, definition)
+	}
+
+	// Create or update the upstream parameter if needed.
+	if createUpstream || updateUpstream {
+		_, err := rmqc.PutFederationUpstream(vhost, upstream, definition)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error putting federation upstream %s on vhost %s", upstream, vhost)
+		}
+
+		// Create an event.
+		var event, eventMessage string
+		if createUpstream {
+			event = "FederationUpstreamCreated"
+			eventMessage = "created"
+		} else {
+			event = "FederationUpstreamUpdated"
+			eventMessage = "updated"
+		}
+		ctx.Events.Eventf(obj, "Normal", event, "RabbitMQ federation upstream %s on vhost %s %s", upstream, vhost, eventMessage)
+	}
+	ctx.Conditions.SetfTrue("FederationUpstreamReady", "FederationUpstreamExists", "RabbitMQ federation upstream %s on vhost %s exists", upstream, vhost)
+
+	// Check the status of any federation links using this upstream.
+	links, err := rmqc.ListFederationLinksIn(vhost)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error listing federation links on vhost %s", vhost)
+	}
+	var running int
+	starting := []string{}
+	broken := []string{}
+	for _, link := range links {
+		if linkUpstream, _ := link["upstream"].(string); linkUpstream != upstream {
+			continue
+		}
+		status, _ := link["status"].(string)
+		switch status {
+		case "running":
+			running++
+		case "starting":
+			starting = append(starting, federationLinkName(link))
+		default:
+			message := fmt.Sprintf("%s is %s", federationLinkName(link), status)
+			if linkError, ok := link["error"].(string); ok && linkError != "" {
+				message += ": " + linkError
+			}
+			broken = append(broken, message)
+		}
+	}
+	sort.Strings(starting)
+	sort.Strings(broken)
+
+	if len(broken) != 0 {
+		ctx.Conditions.SetfFalse("FederationLinksReady", "LinkError", "RabbitMQ federation links for upstream %s are not running: %s", upstream, strings.Join(broken, ", "))
+		return cu.Result{RequeueAfter: time.Minute}, nil
+	}
+	if len(starting) != 0 {
+		ctx.Conditions.SetfFalse("FederationLinksReady", "LinkStarting", "RabbitMQ federation links for upstream %s are starting: %s", upstream, strings.Join(starting, ", "))
+		return cu.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+	if running == 0 {
+		// No policy is using this upstream yet, which isn't an error. Check back for links showing up.
+		ctx.Conditions.SetfTrue("FederationLinksReady", "NoLinks", "RabbitMQ federation upstream %s has no links", upstream)
+		return cu.Result{RequeueAfter: time.Minute}, nil
+	}
+	// Keep checking in case a link breaks later on.
+	ctx.Conditions.SetfTrue("FederationLinksReady", "LinksRunning", "RabbitMQ federation upstream %s has %d running links", upstream, running)
+	return cu.Result{RequeueAfter: time.Minute}, nil
+}
+
+func (comp *federationUpstreamComponent) Finalize(ctx *cu.Context) (cu.Result, bool, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitFederationUpstream)
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, false, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	_, err = rmqc.DeleteFederationUpstream(obj.Spec.Vhost, obj.Spec.UpstreamName)
+	if err != nil {
+		return cu.Result{}, false, errors.Wrapf(err, "error deleting rabbitmq federation upstream %s on vhost %s", obj.Spec.UpstreamName, obj.Spec.Vhost)
+	}
+	return cu.Result{}, true, nil
+}
+
+// Human-readable name for a federation link, like "exchange foo".
+func federationLinkName(link map[string]interface{}) string {
+	linkType, _ := link["type"].(string)
+	name, _ := link[linkType].(string)
+	return fmt.Sprintf("%s %s", linkType, name)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package components
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	cu "github.com/coderanger/controller-utils"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	"github.com/pkg/errors"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+type operatorPoliciesComponent struct {
+	clientFactory rabbitClientFactory
+}
+
+func OperatorPolicies() *operatorPoliciesComponent {
+	return &operatorPoliciesComponent{clientFactory: rabbitholeClientFactory}
+}
+
+func (comp *operatorPoliciesComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitVhost)
+	ctx.Conditions.SetUnknown("OperatorPoliciesReady", "Unknown")
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	// Get the core data for the vhost from the object/context.
+	vhost := obj.Spec.VhostName
+
+	// Process the spec operator policies into a more usable state.
+	desiredPolicies := map[string]*rabbithole.Policy{}
+	for name, specPolicy := range obj.Spec.OperatorPolicies {
+		// Also defaulted by the webhook, but that might not be running. RabbitMQ always reports it so it has to match.
+		applyTo := specPolicy.ApplyTo
+		if applyTo == "" {
+			applyTo = "queues"
+		}
+		policy := &rabbithole.Policy{
+			Vhost:    vhost,
+			Pattern:  specPolicy.Pattern,
+			ApplyTo:  applyTo,
+			Name:     fmt.Sprintf("%s-%s", vhost, name),
+			Priority: specPolicy.Priority,
+		}
+		err := json.Unmarshal(specPolicy.Definition.Raw, &policy.Definition)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error parsing operator policy definition %s for vhost %s/%s", name, obj.Namespace, obj.Name)
+		}
+		desiredPolicies[policy.Name] = policy
+	}
+
+	// Grab and process the existing operator policies.
+	existingPolicyList, err := rmqc.ListOperatorPoliciesIn(vhost)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error fetching operator policies for vhost %s", vhost)
+	}
+	existingPolicies := map[string]*rabbithole.Policy{}
+	for _, existingPolicy := range existingPolicyList {
+		policyCopy := existingPolicy
+		existingPolicies[existingPolicy.Name] = &policyCopy
+	}
+
+	// Double-ended diff the two sets of operator policies.
+	var createPolicies, updatePolicies []*rabbithole.Policy
+	var deletePolicies []string
+	for name, policy := range desiredPolicies {
+		existingPolicy, ok := existingPolicies[name]
+		if !ok {
+			createPolicies = append(createPolicies, policy)
+		} else if !reflect.DeepEqual(*policy, *existingPolicy) {
+			updatePolicies = append(updatePolicies, policy)
+		}
+	}
+	for name := range existingPolicies {
+		_, ok := desiredPolicies[name]
+		if !ok {
+			deletePolicies = append(deletePolicies, name)
+		}
+	}
+
+	// Create needed operator policies.
+	for _, policy := range createPolicies {
+		_, err = rmqc.PutOperatorPolicy(vhost, policy.Name, *policy)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error creating operator policy %s for vhost %s", policy.Name, vhost)
+		}
+		ctx.Events.Eventf(obj, "Normal", "OperatorPolicyCreated", "RabbitMQ operator policy %s for vhost %s created", policy.Name, vhost)
+	}
+
+	// Update needed operator polices.
+	for _, policy := range updatePolicies {
+		_, err = rmqc.PutOperatorPolicy(vhost, policy.Name, *policy)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error updating operator policy %s for vhost %s", policy.Name, vhost)
+		}
+		ctx.Events.Eventf(obj, "Normal", "OperatorPolicyUpdated", "RabbitMQ operator policy %s for vhost %s updated", policy.Name, vhost)
+	}
+
+	// Delete unneeded operator policies.
+	for _, policy := range deletePolicies {
+		_, err = rmqc.DeleteOperatorPolicy(vhost, policy)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error deleting operator policy %s for vhost %s", policy, vhost)
+		}
+		ctx.Events.Eventf(obj, "Normal", "OperatorPolicyDeleted", "RabbitMQ operator policy %s for vhost %s deleted", policy, vhost)
+	}
+
+	ctx.Conditions.SetTrue("OperatorPoliciesReady", "OperatorPoliciesSynced")
+	return cu.Result{}, nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	cu "github.com/coderanger/controller-utils"
+	. "github.com/coderanger/controller-utils/tests/matchers"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("OperatorPolicies component", func() {
+	var obj *rabbitv1beta1.RabbitVhost
+	var rabbit *fakeRabbitClient
+	var helper *cu.UnitHelper
+
+	BeforeEach(func() {
+		rabbit = newFakeRabbitClient()
+		comp := OperatorPolicies()
+		comp.clientFactory = rabbit.Factory
+		obj = &rabbitv1beta1.RabbitVhost{
+			Spec: rabbitv1beta1.RabbitVhostSpec{
+				Connection: rabbitv1beta1.RabbitConnection{
+					Host:     "testhost",
+					Username: "testuser",
+				},
+				OperatorPolicies: map[string]rabbitv1beta1.RabbitPolicy{},
+			},
+		}
+		helper = suiteHelper.Setup(comp, obj)
+	})
+
+	It("creates a policy", func() {
+		obj.Spec.OperatorPolicies["testpol"] = rabbitv1beta1.RabbitPolicy{
+			Pattern: ".*",
+			Definition: runtime.RawExtension{
+				Raw: []byte(`{"max-length": 1000}`),
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.OperatorPolicies).To(MatchAllKeys(Keys{
+			"testing": MatchAllKeys(Keys{
+				"testing-testpol": PointTo(MatchFields(IgnoreExtras, Fields{
+					"Pattern": Equal(".*"),
+					"Definition": MatchAllKeys(Keys{
+						"max-length": BeNumerically("==", 1000),
+					}),
+				})),
+			}),
+		}))
+		Expect(helper.Events).To(Receive(Equal("Normal OperatorPolicyCreated RabbitMQ operator policy testing-testpol for vhost testing created")))
+		Expect(obj).To(HaveCondition("OperatorPoliciesReady").WithStatus("True"))
+	})
+
+	It("does not update a matching policy without applyTo", func() {
+		obj.Spec.OperatorPolicies["testpol"] = rabbitv1beta1.RabbitPolicy{
+			Pattern: ".*",
+			Definition: runtime.RawExtension{
+				Raw: []byte(`{"max-length": 1000}`),
+			},
+		}
+		rabbit.OperatorPolicies = map[string]map[string]*rabbithole.Policy{
+			"testing": {
+				"testing-testpol": {
+					Vhost:   "testing",
+					Name:    "testing-testpol",
+					Pattern: ".*",
+					ApplyTo: "queues",
+					Definition: rabbithole.PolicyDefinition{
+						"max-length": float64(1000),
+					},
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(helper.Events).ToNot(Receive())
+		Expect(obj).To(HaveCondition("OperatorPoliciesReady").WithStatus("True"))
+	})
+
+	It("updates a non-matching policy", func() {
+		obj.Spec.OperatorPolicies["testpol"] = rabbitv1beta1.RabbitPolicy{
+			Pattern: ".*",
+			Definition: runtime.RawExtension{
+				Raw: []byte(`{"max-length": 1000}`),
+			},
+		}
+		rabbit.OperatorPolicies = map[string]map[string]*rabbithole.Policy{
+			"testing": {
+				"testing-testpol": {
+					Vhost:   "testing",
+					Name:    "testing-testpol",
+					Pattern: ".*",
+					Definition: rabbithole.PolicyDefinition{
+						"max-length": 10,
+					},
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.OperatorPolicies).To(MatchAllKeys(Keys{
+			"testing": MatchAllKeys(Keys{
+				"testing-testpol": PointTo(MatchFields(IgnoreExtras, Fields{
+					"Pattern": Equal(".*"),
+					"Definition": MatchAllKeys(Keys{
+						"max-length": BeNumerically("==", 1000),
+					}),
+				})),
+			}),
+		}))
+		Expect(helper.Events).To(Receive(Equal("Normal OperatorPolicyUpdated RabbitMQ operator policy testing-testpol for vhost testing updated")))
+		Expect(obj).To(HaveCondition("OperatorPoliciesReady").WithStatus("True"))
+	})
+
+	It("deletes a policy", func() {
+		rabbit.OperatorPolicies = map[string]map[string]*rabbithole.Policy{
+			"testing": {
+				"testing-testpol": {
+					Vhost:   "testing",
+					Name:    "testing-testpol",
+					Pattern: ".*",
+					Definition: rabbithole.PolicyDefinition{
+						"max-length": 10,
+					},
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.OperatorPolicies).To(MatchAllKeys(Keys{
+			"testing": BeEmpty(),
+		}))
+		Expect(helper.Events).To(Receive(Equal("Normal OperatorPolicyDeleted RabbitMQ operator policy testing-testpol for vhost testing deleted")))
+		Expect(obj).To(HaveCondition("OperatorPoliciesReady").WithStatus("True"))
+	})
+})
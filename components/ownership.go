@@ -0,0 +1,108 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"strings"
+
+	cu "github.com/coderanger/controller-utils"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// Annotation to adopt an existing broker object, same as setting spec.adopt.
+	adoptAnnotation = "rabbitmq.coderanger.net/adopt"
+	// Queue argument recording the UID of the owning object. Arguments can only be set when a queue is declared so
+	// adopted queues go without, and adoption is refused while another RabbitQueue has the queue in its status.
+	ownerArgument = "x-rabbitmq-operator-owner"
+	// Prefix for the user and vhost tag recording the UID of the owning object.
+	ownerTagPrefix = "rabbitmq-operator-owner:"
+
+	// Finalizers added by the controllers for the components that call checkOwnership.
+	queueFinalizer = "rabbitqueue.rabbitmq.coderanger.net/queue"
+	vhostFinalizer = "rabbitvhost.rabbitmq.coderanger.net/vhost"
+	userFinalizer  = "rabbituser.rabbitmq.coderanger.net/user"
+)
+
+// Called before anything else in Reconcile. An object that has neither taken ownership nor the finalizer yet is new,
+// so mark it as unproven until it creates or adopts the broker object. The controller adds the finalizer even when
+// Reconcile fails, so without this the next reconcile would take it for an object from before ownership was tracked.
+func startOwnershipCheck(obj cu.Object, finalizer string, owned, conflict *bool) {
+	if !*owned && !controllerutil.ContainsFinalizer(obj, finalizer) {
+		*conflict = true
+	}
+}
+
+// Check if the object is allowed to manage a broker object that already exists. An owner recorded on the broker always
+// wins, otherwise it has to have been created or adopted by this object. Returns false with the ready condition set
+// if the component should leave it alone.
+func checkOwnership(ctx *cu.Context, kind, description string, adopt bool, finalizer string, owned, conflict *bool, brokerOwner string) bool {
+	obj := ctx.Object
+	uid := string(obj.GetUID())
+	if brokerOwner != "" && brokerOwner != uid {
+		*conflict = true
+		ctx.Conditions.SetfFalse(kind+"Ready", "OwnedByOther", "%s is managed by another object with UID %s", description, brokerOwner)
+		return false
+	}
+	// Past the check above, any owner recorded on the broker is this object.
+	if brokerOwner != "" || *owned {
+		*owned = true
+		*conflict = false
+		return true
+	}
+	if adopt || obj.GetAnnotations()[adoptAnnotation] == "true" {
+		*owned = true
+		*conflict = false
+		ctx.Events.Eventf(obj, "Normal", kind+"Adopted", "%s adopted", description)
+		return true
+	}
+	// Objects from before ownership was tracked already have the finalizer from managing it. Newer objects are marked
+	// by startOwnershipCheck before they could get the finalizer, hence the conflict flag.
+	if !*conflict && controllerutil.ContainsFinalizer(obj, finalizer) {
+		*owned = true
+		return true
+	}
+	*conflict = true
+	ctx.Conditions.SetfFalse(kind+"Ready", "AlreadyExists", "%s already exists, set spec.adopt to manage it", description)
+	return false
+}
+
+// Find the owner UID in a list of user or vhost tags.
+func ownerFromTags(tags []string) string {
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, ownerTagPrefix) {
+			return strings.TrimPrefix(tag, ownerTagPrefix)
+		}
+	}
+	return ""
+}
+
+// Strip the owner tag so the rest can be compared with the spec.
+func withoutOwnerTags(tags []string) []string {
+	filtered := []string{}
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, ownerTagPrefix) {
+			filtered = append(filtered, tag)
+		}
+	}
+	return filtered
+}
+
+// Add the owner tag for the current object to a list of tags.
+func withOwnerTag(ctx *cu.Context, tags []string) []string {
+	return append(withoutOwnerTags(tags), ownerTagPrefix+string(ctx.Object.GetUID()))
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"io/ioutil"
+	"net/url"
+	"time"
+
+	cu "github.com/coderanger/controller-utils"
+	"github.com/coderanger/controller-utils/randstring"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+// Changing the value of this annotation rotates the password once.
+const rotatePasswordAnnotation = "rabbitmq.coderanger.net/rotate-password"
+
+// Same length as the passwords from RandomSecretComponent.
+const rotatedPasswordBytes = 32
+
+type passwordRotationComponent struct {
+	clientFactory rabbitClientFactory
+}
+
+func PasswordRotation() *passwordRotationComponent {
+	return &passwordRotationComponent{clientFactory: rabbitholeClientFactory}
+}
+
+// Rotate the password by putting a new password on whichever of the user and its shadow isn't live, switching the
+// Secret over to it, and then deleting the previous user once the grace period is up. The Secret itself is written by
+// the template component, so this has to run after the user component and before the template.
+func (comp *passwordRotationComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitUser)
+	rotation := obj.Status.PasswordRotation
+	// Even with rotation turned off, finish retiring the previous user.
+	if obj.Spec.PasswordRotation == nil && (rotation == nil || rotation.Phase != "Retiring") {
+		return cu.Result{}, nil
+	}
+
+	// The user component stashes this once the live user is in place.
+	uri, ok := ctx.Data["uri"].(*url.URL)
+	if !ok {
+		return cu.Result{}, nil
+	}
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	nonce := obj.Annotations[rotatePasswordAnnotation]
+	if rotation == nil {
+		// Start the clock from when rotation is turned on, the current password is new enough.
+		obj.Status.PasswordRotation = &rabbitv1beta1.RabbitUserPasswordRotation{
+			Phase:        "Idle",
+			LastRotation: metav1.Now(),
+			Nonce:        nonce,
+		}
+		return comp.nextRotation(obj), nil
+	}
+
+	gracePeriod := time.Hour
+	if obj.Spec.PasswordRotation != nil && obj.Spec.PasswordRotation.GracePeriod != nil {
+		gracePeriod = obj.Spec.PasswordRotation.GracePeriod.Duration
+	}
+	if rotation.Phase == "Retiring" {
+		remaining := time.Until(rotation.LastRotation.Add(gracePeriod))
+		if remaining > 0 {
+			return cu.Result{RequeueAfter: remaining}, nil
+		}
+		_, err = rmqc.DeleteUser(rotation.RetiringUsername)
+		if err != nil {
+			// Already gone is just as good, otherwise it would be stuck retiring forever.
+			rabbitErr, ok := err.(rabbithole.ErrorResponse)
+			if !ok || rabbitErr.StatusCode != 404 {
+				return cu.Result{}, errors.Wrapf(err, "error deleting rabbitmq user %s", rotation.RetiringUsername)
+			}
+		}
+		ctx.Events.Eventf(obj, "Normal", "UserRetired", "RabbitMQ user %s retired after password rotation", rotation.RetiringUsername)
+		rotation.Phase = "Idle"
+		rotation.RetiringUsername = ""
+	}
+	if obj.Spec.PasswordRotation == nil {
+		return cu.Result{}, nil
+	}
+
+	// Check if a rotation is due.
+	due := nonce != "" && nonce != rotation.Nonce
+	interval := obj.Spec.PasswordRotation.Interval
+	if interval != nil && !time.Now().Before(rotation.LastRotation.Add(interval.Duration)) {
+		due = true
+	}
+	if !due {
+		return comp.nextRotation(obj), nil
+	}
+
+	// Put the new password on the other user, making sure it isn't someone else's.
+	oldUsername := obj.ActiveUsername()
+	newUsername := obj.Spec.Username
+	if !rotation.Shadow {
+		newUsername += rabbitv1beta1.ShadowUserSuffix
+	}
+	existingUser, err := rmqc.GetUser(newUsername)
+	if err != nil {
+		rabbitErr, ok := err.(rabbithole.ErrorResponse)
+		if !ok || rabbitErr.StatusCode != 404 {
+			return cu.Result{}, errors.Wrapf(err, "error getting user %s", newUsername)
+		}
+	} else {
+		owner := ownerFromTags(existingUser.Tags)
+		if owner != string(obj.UID) {
+			return cu.Result{}, errors.Errorf("shadow user %s already exists and is not managed by this object", newUsername)
+		}
+	}
+	password, err := randstring.RandomString(rotatedPasswordBytes)
+	if err != nil {
+		return cu.Result{}, errors.Wrap(err, "error generating password")
+	}
+	algorithm, err := userHashingAlgorithm(obj)
+	if err != nil {
+		return cu.Result{}, err
+	}
+	hashedPassword, err := hashRabbitPassword(password, algorithm, "")
+	if err != nil {
+		return cu.Result{}, errors.Wrap(err, "error hashing password for put")
+	}
+	resp, err := rmqc.PutUser(newUsername, rabbithole.UserSettings{PasswordHash: hashedPassword, HashingAlgorithm: algorithm, Tags: userTags(ctx, obj)})
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error putting user %s", newUsername)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error reading PutUser response: %s", resp.Status)
+		}
+		return cu.Result{}, errors.Errorf("error putting user %s: %s %s", newUsername, resp.Status, body)
+	}
+
+	// Switch over, the later components give the new user its permissions and limits and then write the Secret.
+	rotation.Phase = "Retiring"
+	rotation.LastRotation = metav1.Now()
+	rotation.Nonce = nonce
+	rotation.Shadow = !rotation.Shadow
+	rotation.RetiringUsername = oldUsername
+	newURI := *uri
+	newURI.User = url.UserPassword(newUsername, password)
+	ctx.Data["uri"] = &newURI
+	ctx.Data["username"] = newUsername
+	ctx.Data["RABBIT_PASSWORD"] = password
+	ctx.Events.Eventf(obj, "Normal", "PasswordRotated", "RabbitMQ user %s password rotated onto %s", oldUsername, newUsername)
+	return cu.Result{RequeueAfter: gracePeriod}, nil
+}
+
+// Check back when the next scheduled rotation is due.
+func (_ *passwordRotationComponent) nextRotation(obj *rabbitv1beta1.RabbitUser) cu.Result {
+	interval := obj.Spec.PasswordRotation.Interval
+	if interval == nil {
+		return cu.Result{}
+	}
+	return cu.Result{RequeueAfter: time.Until(obj.Status.PasswordRotation.LastRotation.Add(interval.Duration))}
+}
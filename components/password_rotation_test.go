@@ -0,0 +1,171 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"net/url"
+	"time"
+
+	cu "github.com/coderanger/controller-utils"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("PasswordRotation component", func() {
+	var obj *rabbitv1beta1.RabbitUser
+	var rabbit *fakeRabbitClient
+	var helper *cu.UnitHelper
+
+	BeforeEach(func() {
+		rabbit = newFakeRabbitClient()
+		rabbit.Users = []*rabbithole.UserInfo{
+			{Name: "testing", Tags: rabbithole.UserTags{"rabbitmq-operator-owner:testing-uid"}},
+		}
+		comp := PasswordRotation()
+		comp.clientFactory = rabbit.Factory
+		obj = &rabbitv1beta1.RabbitUser{
+			ObjectMeta: metav1.ObjectMeta{UID: "testing-uid"},
+			Spec: rabbitv1beta1.RabbitUserSpec{
+				PasswordRotation: &rabbitv1beta1.RabbitPasswordRotation{
+					Interval: &metav1.Duration{Duration: 90 * 24 * time.Hour},
+				},
+				Connection: rabbitv1beta1.RabbitConnection{
+					Host:     "testhost",
+					Username: "testuser",
+				},
+			},
+			Status: rabbitv1beta1.RabbitUserStatus{Owned: true},
+		}
+		helper = suiteHelper.Setup(comp, obj)
+		helper.Ctx.Data["uri"] = &url.URL{Scheme: "amqp", Host: "testhost", User: url.UserPassword("testing", "oldpassword")}
+		helper.Ctx.Data["RABBIT_PASSWORD"] = "oldpassword"
+	})
+
+	It("starts the clock without rotating", func() {
+		res := helper.MustReconcile()
+		Expect(obj.Status.PasswordRotation).To(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Phase":  Equal("Idle"),
+			"Shadow": BeFalse(),
+		})))
+		Expect(res.RequeueAfter).To(BeNumerically("~", 90*24*time.Hour, time.Minute))
+		Expect(rabbit.Users).To(HaveLen(1))
+	})
+
+	It("does nothing without passwordRotation", func() {
+		obj.Spec.PasswordRotation = nil
+		helper.MustReconcile()
+		Expect(obj.Status.PasswordRotation).To(BeNil())
+	})
+
+	It("rotates onto the shadow user when due", func() {
+		obj.Status.PasswordRotation = &rabbitv1beta1.RabbitUserPasswordRotation{
+			Phase:        "Idle",
+			LastRotation: metav1.NewTime(time.Now().Add(-91 * 24 * time.Hour)),
+		}
+		res := helper.MustReconcile()
+		Expect(rabbit.Users).To(ContainElement(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Name":         Equal("testing-shadow"),
+			"PasswordHash": MatchRabbitPassword(helper.Ctx.Data["RABBIT_PASSWORD"].(string)),
+			"Tags":         ContainElement("rabbitmq-operator-owner:testing-uid"),
+		}))))
+		Expect(helper.Ctx.Data["RABBIT_PASSWORD"]).ToNot(Equal("oldpassword"))
+		Expect(helper.Ctx.Data["username"]).To(Equal("testing-shadow"))
+		Expect(helper.Ctx.Data["uri"].(*url.URL).User.Username()).To(Equal("testing-shadow"))
+		Expect(obj.Status.PasswordRotation).To(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Phase":            Equal("Retiring"),
+			"Shadow":           BeTrue(),
+			"RetiringUsername": Equal("testing"),
+		})))
+		Expect(obj.ActiveUsername()).To(Equal("testing-shadow"))
+		Expect(res.RequeueAfter).To(Equal(time.Hour))
+		Expect(helper.Events).To(Receive(Equal("Normal PasswordRotated RabbitMQ user testing password rotated onto testing-shadow")))
+	})
+
+	It("rotates when the annotation changes", func() {
+		obj.Annotations = map[string]string{"rabbitmq.coderanger.net/rotate-password": "1"}
+		obj.Status.PasswordRotation = &rabbitv1beta1.RabbitUserPasswordRotation{
+			Phase:        "Idle",
+			LastRotation: metav1.Now(),
+		}
+		helper.MustReconcile()
+		Expect(obj.Status.PasswordRotation.Phase).To(Equal("Retiring"))
+		Expect(obj.Status.PasswordRotation.Nonce).To(Equal("1"))
+
+		// Finishing the grace period doesn't rotate again for the same nonce.
+		obj.Status.PasswordRotation.LastRotation = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+		helper.MustReconcile()
+		Expect(obj.Status.PasswordRotation.Phase).To(Equal("Idle"))
+		Expect(rabbit.Users).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{"Name": Equal("testing-shadow")}))))
+	})
+
+	It("keeps the previous user during the grace period", func() {
+		rabbit.Users = append(rabbit.Users, &rabbithole.UserInfo{Name: "testing-shadow", Tags: rabbithole.UserTags{"rabbitmq-operator-owner:testing-uid"}})
+		obj.Status.PasswordRotation = &rabbitv1beta1.RabbitUserPasswordRotation{
+			Phase:            "Retiring",
+			LastRotation:     metav1.NewTime(time.Now().Add(-30 * time.Minute)),
+			Shadow:           true,
+			RetiringUsername: "testing",
+		}
+		res := helper.MustReconcile()
+		Expect(rabbit.Users).To(HaveLen(2))
+		Expect(res.RequeueAfter).To(BeNumerically("~", 30*time.Minute, time.Minute))
+	})
+
+	It("retires the previous user after the grace period", func() {
+		rabbit.Users = append(rabbit.Users, &rabbithole.UserInfo{Name: "testing-shadow", Tags: rabbithole.UserTags{"rabbitmq-operator-owner:testing-uid"}})
+		obj.Status.PasswordRotation = &rabbitv1beta1.RabbitUserPasswordRotation{
+			Phase:            "Retiring",
+			LastRotation:     metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+			Shadow:           true,
+			RetiringUsername: "testing",
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Users).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{"Name": Equal("testing-shadow")}))))
+		Expect(obj.Status.PasswordRotation.Phase).To(Equal("Idle"))
+		Expect(obj.Status.PasswordRotation.RetiringUsername).To(Equal(""))
+		Expect(helper.Events).To(Receive(Equal("Normal UserRetired RabbitMQ user testing retired after password rotation")))
+	})
+
+	It("retires the previous user even if it is already gone", func() {
+		rabbit.Users = []*rabbithole.UserInfo{{Name: "testing-shadow", Tags: rabbithole.UserTags{"rabbitmq-operator-owner:testing-uid"}}}
+		obj.Status.PasswordRotation = &rabbitv1beta1.RabbitUserPasswordRotation{
+			Phase:            "Retiring",
+			LastRotation:     metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+			Shadow:           true,
+			RetiringUsername: "testing",
+		}
+		helper.MustReconcile()
+		Expect(obj.Status.PasswordRotation.Phase).To(Equal("Idle"))
+		Expect(obj.Status.PasswordRotation.RetiringUsername).To(Equal(""))
+	})
+
+	It("refuses to take over a shadow user it doesn't own", func() {
+		rabbit.Users = append(rabbit.Users, &rabbithole.UserInfo{Name: "testing-shadow"})
+		obj.Status.PasswordRotation = &rabbitv1beta1.RabbitUserPasswordRotation{
+			Phase:        "Idle",
+			LastRotation: metav1.NewTime(time.Now().Add(-91 * 24 * time.Hour)),
+		}
+		_, err := helper.Reconcile()
+		Expect(err).To(MatchError("shadow user testing-shadow already exists and is not managed by this object"))
+		Expect(obj.Status.PasswordRotation.Phase).To(Equal("Idle"))
+	})
+})
@@ -56,7 +56,7 @@ func (comp *permissionsComponent) Setup(ctx *cu.Context, bldr *ctrl.Builder) err
 }
 
 // Watch map function used above.
-// Obj is a Vhost that just got an event, map it back to any User with * permissions.
+// Obj is a Vhost that just got an event, map it back to any User with * permissions or topic permissions.
 func (wm *permissionsComponentWatchMap) Map(obj handler.MapObject) []reconcile.Request {
 	requests := []reconcile.Request{}
 	// Find any User objects that have * vhost permissions so they can be updated.
@@ -68,16 +68,13 @@ func (wm *permissionsComponentWatchMap) Map(obj handler.MapObject) []reconcile.R
 		return requests
 	}
 	for _, user := range users.Items {
-		for _, perm := range user.Spec.Permissions {
-			if perm.Vhost == "*" {
-				requests = append(requests, reconcile.Request{
-					NamespacedName: types.NamespacedName{
-						Name:      user.Name,
-						Namespace: user.Namespace,
-					},
-				})
-				break
-			}
+		if hasAllVhostPermissions(&user) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      user.Name,
+					Namespace: user.Namespace,
+				},
+			})
 		}
 	}
 	return requests
@@ -94,7 +91,7 @@ func (comp *permissionsComponent) Reconcile(ctx *cu.Context) (cu.Result, error)
 	}
 
 	// Get the core data for the user from the object/context.
-	username := obj.Spec.Username
+	username := obj.ActiveUsername()
 	if username == "" { // TODO Switch this to a defaulting webhook.
 		username = obj.Name
 	}
@@ -183,3 +180,18 @@ func (comp *permissionsComponent) Reconcile(ctx *cu.Context) (cu.Result, error)
 	ctx.Conditions.SetTrue("PermissionsReady", "PermissionsSynced")
 	return cu.Result{}, nil
 }
+
+// Check if a user has any permissions or topic permissions using the * pseudo-vhost.
+func hasAllVhostPermissions(user *rabbitv1beta1.RabbitUser) bool {
+	for _, perm := range user.Spec.Permissions {
+		if perm.Vhost == "*" {
+			return true
+		}
+	}
+	for _, perm := range user.Spec.TopicPermissions {
+		if perm.Vhost == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -180,7 +180,7 @@ var _ = Describe("Permissions component", func() {
 	})
 
 	It("decodes a * vhost as 'all vhosts'", func() {
-		rabbit.Vhosts = []*rabbithole.VhostInfo{
+		rabbit.Vhosts = []*vhostInfo{
 			{
 				Name: "testing",
 			},
@@ -201,7 +201,7 @@ var _ = Describe("Permissions component", func() {
 	})
 
 	It("doesn't overwrite a more specific vhost with the * permissions", func() {
-		rabbit.Vhosts = []*rabbithole.VhostInfo{
+		rabbit.Vhosts = []*vhostInfo{
 			{
 				Name: "testing1",
 			},
@@ -20,17 +20,25 @@ package components
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
 	cu "github.com/coderanger/controller-utils"
 	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
 	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
 
 	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
 )
 
+// Annotation to purge a queue once for each new value.
+const purgeAnnotation = "rabbitmq.coderanger.net/purge"
+
 type queueComponent struct {
 	clientFactory rabbitClientFactory
 }
@@ -43,9 +51,14 @@ func (_ *queueComponent) GetReadyCondition() string {
 	return "QueueReady"
 }
 
+func (comp *queueComponent) Setup(ctx *cu.Context, bldr *ctrl.Builder) error {
+	return watchClusters(ctx, bldr)
+}
+
 func (comp *queueComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 	obj := ctx.Object.(*rabbitv1beta1.RabbitQueue)
 	ctx.Conditions.SetUnknown("QueueReady", "Unknown")
+	startOwnershipCheck(obj, queueFinalizer, &obj.Status.Owned, &obj.Status.OwnershipConflict)
 
 	// Connect to the RabbitMQ server.
 	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
@@ -54,8 +67,35 @@ func (comp *queueComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 	}
 
 	// Get the core data for the queue from the object/context.
-	queue := obj.Spec.QueueName
+	queue, err := obj.ResolveQueueName()
+	if err != nil {
+		return cu.Result{}, err
+	}
 	vhost := obj.Spec.Vhost
+	args, err := queueArguments(&obj.Spec)
+	if err != nil {
+		return cu.Result{}, errors.Wrap(err, "error parsing arguments")
+	}
+	settings := rabbithole.QueueSettings{}
+	if obj.Spec.AutoDelete != nil {
+		settings.AutoDelete = *obj.Spec.AutoDelete
+	}
+	if obj.Spec.Durable != nil {
+		settings.Durable = *obj.Spec.Durable
+	}
+	// Record the owner on the broker, which can only be done when the queue is declared.
+	settings.Arguments = map[string]interface{}{ownerArgument: string(obj.UID)}
+	for key, val := range args {
+		settings.Arguments[key] = val
+	}
+
+	// Finish any migration already in progress before looking at anything else.
+	if obj.Status.Migration != nil {
+		res, done, err := comp.migrate(ctx, rmqc, obj, nil, settings)
+		if err != nil || !done {
+			return res, err
+		}
+	}
 
 	// Check if the queue already exists. There is nothing to update since there's no secondary values (for now, maybe tracing later).
 	var createQueue bool
@@ -67,61 +107,124 @@ func (comp *queueComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 		} else {
 			return cu.Result{}, errors.Wrapf(err, "error getting queue %s on vhost %s", queue, vhost)
 		}
+	} else {
+		owner, _ := existingQueue.Arguments[ownerArgument].(string)
+		// Adopted queues never get the owner argument, so check nothing else has adopted it already.
+		if owner == "" && !obj.Status.Owned {
+			claimant, err := queueClaimant(ctx, obj, vhost, queue)
+			if err != nil {
+				return cu.Result{}, err
+			}
+			if claimant != "" {
+				obj.Status.OwnershipConflict = true
+				ctx.Conditions.SetfFalse("QueueReady", "OwnedByOther", "RabbitMQ queue %s on vhost %s is managed by RabbitQueue %s", queue, vhost, claimant)
+				return cu.Result{SkipRemaining: true}, nil
+			}
+		}
+		if !checkOwnership(ctx, "Queue", fmt.Sprintf("RabbitMQ queue %s on vhost %s", queue, vhost), obj.Spec.Adopt, queueFinalizer, &obj.Status.Owned, &obj.Status.OwnershipConflict, owner) {
+			return cu.Result{SkipRemaining: true}, nil
+		}
+		obj.Status.QueueName = queue
+
+		// Purge the queue if requested, before anything else since it might be blocking a settings change.
+		nonce, ok := obj.Annotations[purgeAnnotation]
+		if ok && nonce != "" && (obj.Status.LastPurge == nil || obj.Status.LastPurge.Nonce != nonce) {
+			_, err := rmqc.PurgeQueue(vhost, queue)
+			if err != nil {
+				return cu.Result{}, errors.Wrapf(err, "error purging queue %s on vhost %s", queue, vhost)
+			}
+			obj.Status.LastPurge = &rabbitv1beta1.RabbitQueuePurge{
+				Nonce:    nonce,
+				Messages: existingQueue.Messages,
+				Time:     metav1.Now(),
+			}
+			ctx.Events.Eventf(obj, "Normal", "QueuePurged", "RabbitMQ queue %s on vhost %s purged of %d messages", queue, vhost, obj.Status.LastPurge.Messages)
+		}
+	}
+
+	// Make sure the dead-letter exchange is there before anything gets dead-lettered to it.
+	err = comp.declareDeadLetter(ctx, rmqc, obj)
+	if err != nil {
+		return cu.Result{}, err
 	}
 
 	// If the queue already exists, check if the spec fields match the current params. If not, flag for recreate.
 	if !createQueue {
 		validationErrors := []string{}
+		existingType := queueType(existingQueue.Arguments)
+		desiredType := queueType(args)
+		if existingType != desiredType {
+			validationErrors = append(validationErrors, fmt.Sprintf("Type currently %s expecting %s", existingType, desiredType))
+		}
 		if obj.Spec.AutoDelete != nil && existingQueue.AutoDelete != *obj.Spec.AutoDelete {
 			validationErrors = append(validationErrors, fmt.Sprintf("AutoDelete currently %v expecting %v", existingQueue.AutoDelete, *obj.Spec.AutoDelete))
 		}
 		if obj.Spec.Durable != nil && existingQueue.Durable != *obj.Spec.Durable {
 			validationErrors = append(validationErrors, fmt.Sprintf("Durable currently %v expecting %v", existingQueue.Durable, *obj.Spec.Durable))
 		}
-		if obj.Spec.Arguments != nil {
-			var args map[string]interface{}
-			err = json.Unmarshal(obj.Spec.Arguments.Raw, &args)
-			if err != nil {
-				return cu.Result{}, errors.Wrap(err, "error parsing arguments")
+		driftedArgs := []string{}
+		for key, val := range args {
+			// Already checked above.
+			if key == "x-queue-type" {
+				continue
 			}
-
-			for key, val := range args {
-				existingVal, ok := existingQueue.Arguments[key]
-				if !ok {
-					validationErrors = append(validationErrors, fmt.Sprintf("Argument %s currently <not set> expecting %v", key, val))
-
-				} else if !reflect.DeepEqual(existingVal, val) {
-					validationErrors = append(validationErrors, fmt.Sprintf("Argument %s currently %v expecting %v", key, existingVal, val))
+			existingVal, ok := existingQueue.Arguments[key]
+			if !ok {
+				validationErrors = append(validationErrors, fmt.Sprintf("Argument %s currently <not set> expecting %v", key, val))
+				driftedArgs = append(driftedArgs, key)
+			} else if !reflect.DeepEqual(existingVal, val) {
+				validationErrors = append(validationErrors, fmt.Sprintf("Argument %s currently %v expecting %v", key, existingVal, val))
+				driftedArgs = append(driftedArgs, key)
+			}
+		}
+		// Extra arguments are only a problem in Exact mode, otherwise anything set by hand is left alone.
+		if obj.Spec.ArgumentsMode == "Exact" {
+			for key, existingVal := range existingQueue.Arguments {
+				_, ok := args[key]
+				if !ok && key != "x-queue-type" && key != ownerArgument {
+					validationErrors = append(validationErrors, fmt.Sprintf("Argument %s currently %v expecting <not set>", key, existingVal))
+					driftedArgs = append(driftedArgs, key)
 				}
 			}
 		}
-		// Ignore any extra arguments I guess? Not sure the right behavior there.
-		// Try to delete the queue.
+		if len(driftedArgs) != 0 {
+			sort.Strings(driftedArgs)
+			ctx.Conditions.SetfTrue("QueueDrifted", "ArgumentsDrifted", "RabbitMQ queue %s on vhost %s arguments differ: %s", queue, vhost, strings.Join(driftedArgs, ", "))
+		} else {
+			ctx.Conditions.SetfFalse("QueueDrifted", "NoDrift", "RabbitMQ queue %s on vhost %s arguments match", queue, vhost)
+		}
 		if len(validationErrors) != 0 {
-			_, err := rmqc.DeleteQueue(obj.Spec.Vhost, obj.Spec.QueueName, rabbithole.QueueDeleteOptions{IfEmpty: true})
-			if err != nil {
-				return cu.Result{RequeueAfter: time.Minute}, errors.Errorf("queue settings do not match: %s", strings.Join(validationErrors, ", "))
+			switch obj.Spec.MigrationPolicy {
+			case "Never":
+				// Leave the queue alone, just report the problem.
+				if existingType != desiredType {
+					ctx.Conditions.SetfFalse("QueueReady", "QueueTypeMismatch", "RabbitMQ queue %s on vhost %s is a %s queue, expecting %s", queue, vhost, existingType, desiredType)
+				} else {
+					ctx.Conditions.SetfFalse("QueueReady", "QueueSettingsMismatch", "RabbitMQ queue %s on vhost %s settings do not match: %s", queue, vhost, strings.Join(validationErrors, ", "))
+				}
+				return cu.Result{}, nil
+			case "Shovel":
+				res, done, err := comp.migrate(ctx, rmqc, obj, existingQueue, settings)
+				if err != nil || !done {
+					return res, err
+				}
+			default:
+				// Try to delete the queue.
+				_, err := rmqc.DeleteQueue(vhost, queue, rabbithole.QueueDeleteOptions{IfEmpty: true})
+				if err != nil {
+					// The type can never be changed in place so call it out specifically.
+					if existingType != desiredType {
+						ctx.Conditions.SetfFalse("QueueReady", "QueueTypeMismatch", "RabbitMQ queue %s on vhost %s is a %s queue, expecting %s", queue, vhost, existingType, desiredType)
+					}
+					return cu.Result{RequeueAfter: time.Minute}, errors.Errorf("queue settings do not match: %s", strings.Join(validationErrors, ", "))
+				}
+				createQueue = true
 			}
-			createQueue = true
 		}
 	}
 
 	// Create the queue if needed.
 	if createQueue {
-		settings := rabbithole.QueueSettings{}
-		if obj.Spec.AutoDelete != nil {
-			settings.AutoDelete = *obj.Spec.AutoDelete
-		}
-		if obj.Spec.Durable != nil {
-			settings.Durable = *obj.Spec.Durable
-		}
-		if obj.Spec.Arguments != nil {
-			settings.Arguments = map[string]interface{}{}
-			err = json.Unmarshal(obj.Spec.Arguments.Raw, &settings.Arguments)
-			if err != nil {
-				return cu.Result{}, errors.Wrap(err, "error parsing arguments")
-			}
-		}
 		resp, err := rmqc.DeclareQueue(vhost, queue, settings)
 		if err != nil {
 			return cu.Result{}, errors.Wrapf(err, "error creating queue %s on vhost %s", queue, vhost)
@@ -130,6 +233,19 @@ func (comp *queueComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 			return cu.Result{}, errors.Errorf("unable to create queue %s on vhost %s, got response code %v", queue, vhost, resp.StatusCode)
 		}
 		ctx.Events.Eventf(obj, "Normal", "QueueCreated", "RabbitMQ queue %s on vhost %s created", queue, vhost)
+		obj.Status.Owned = true
+		obj.Status.OwnershipConflict = false
+		obj.Status.QueueName = queue
+		ctx.Conditions.SetfFalse("QueueDrifted", "NoDrift", "RabbitMQ queue %s on vhost %s arguments match", queue, vhost)
+
+		// A brand new queue has nothing to purge, record the nonce so the next reconcile doesn't purge it anyway.
+		nonce, ok := obj.Annotations[purgeAnnotation]
+		if ok && nonce != "" && (obj.Status.LastPurge == nil || obj.Status.LastPurge.Nonce != nonce) {
+			obj.Status.LastPurge = &rabbitv1beta1.RabbitQueuePurge{
+				Nonce: nonce,
+				Time:  metav1.Now(),
+			}
+		}
 	}
 
 	ctx.Conditions.SetfTrue("QueueReady", "QueueExists", "RabbitMQ queue %s on vhost %s exists", queue, vhost)
@@ -138,6 +254,22 @@ func (comp *queueComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 
 func (comp *queueComponent) Finalize(ctx *cu.Context) (cu.Result, bool, error) {
 	obj := ctx.Object.(*rabbitv1beta1.RabbitQueue)
+	vhost := obj.Spec.Vhost
+
+	// Never delete a queue this object didn't create or adopt.
+	if !obj.Status.Owned {
+		return cu.Result{}, true, nil
+	}
+
+	queue, err := obj.ResolveQueueName()
+	if err != nil {
+		return cu.Result{}, false, err
+	}
+
+	if obj.Spec.DeletionPolicy == "Retain" {
+		ctx.Events.Eventf(obj, "Normal", "QueueRetained", "RabbitMQ queue %s on vhost %s retained", queue, vhost)
+		return cu.Result{}, true, nil
+	}
 
 	// Connect to the RabbitMQ server.
 	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
@@ -145,9 +277,316 @@ func (comp *queueComponent) Finalize(ctx *cu.Context) (cu.Result, bool, error) {
 		return cu.Result{}, false, errors.Wrapf(err, "error connecting to rabbitmq")
 	}
 
-	_, err = rmqc.DeleteQueue(obj.Spec.Vhost, obj.Spec.QueueName)
+	opts := rabbithole.QueueDeleteOptions{IfEmpty: obj.Spec.DeletionPolicy == "DeleteIfEmpty"}
+	if opts.IfEmpty {
+		existingQueue, err := rmqc.GetQueue(vhost, queue)
+		if err != nil {
+			rabbitErr, ok := err.(rabbithole.ErrorResponse)
+			if ok && rabbitErr.StatusCode == 404 {
+				return comp.deleteDeadLetter(ctx, rmqc, obj, opts)
+			}
+			return cu.Result{}, false, errors.Wrapf(err, "error getting queue %s on vhost %s", queue, vhost)
+		}
+		// The message count lags behind so IfEmpty is what actually protects any stragglers.
+		if existingQueue.Messages != 0 {
+			return deletionBlocked(ctx, "QueueReady", "RabbitMQ queue %s on vhost %s still has %d messages", queue, vhost, existingQueue.Messages), false, nil
+		}
+	}
+
+	_, err = rmqc.DeleteQueue(vhost, queue, opts)
 	if err != nil {
-		return cu.Result{}, false, errors.Wrapf(err, "error deleting rabbitmq queue %s on vhost %s", obj.Spec.QueueName, obj.Spec.Vhost)
+		rabbitErr, ok := err.(rabbithole.ErrorResponse)
+		if ok && opts.IfEmpty && rabbitErr.StatusCode == 400 {
+			return deletionBlocked(ctx, "QueueReady", "RabbitMQ queue %s on vhost %s is not empty", queue, vhost), false, nil
+		}
+		return cu.Result{}, false, errors.Wrapf(err, "error deleting rabbitmq queue %s on vhost %s", queue, vhost)
+	}
+	return comp.deleteDeadLetter(ctx, rmqc, obj, opts)
+}
+
+// Move the messages out to a temporary queue with a shovel, recreate the queue with the new settings, and then move
+// them back. Each step is recorded in the status so it can pick up where it left off. The queue's bindings are copied
+// to the temporary queue first and moved back once the queue is recreated, so publishers keep reaching one or the
+// other throughout. Returns true once the migration is complete.
+func (comp *queueComponent) migrate(ctx *cu.Context, rmqc rabbitManager, obj *rabbitv1beta1.RabbitQueue, existingQueue *rabbithole.DetailedQueueInfo, settings rabbithole.QueueSettings) (cu.Result, bool, error) {
+	queue, err := obj.ResolveQueueName()
+	if err != nil {
+		return cu.Result{}, false, err
+	}
+	vhost := obj.Spec.Vhost
+	shovel := queue + "-migration"
+	// An empty host means the local broker for dynamic shovels.
+	localURI := "amqp:///" + url.PathEscape(vhost)
+
+	migration := obj.Status.Migration
+	if migration == nil {
+		migration = &rabbitv1beta1.RabbitQueueMigration{
+			Phase:          "MovingToTemporary",
+			TemporaryQueue: queue + ".migration",
+			StartTime:      metav1.Now(),
+		}
+		// The default exchange binding comes and goes with the queue itself, everything else has to be copied.
+		bindings, err := rmqc.ListQueueBindings(vhost, queue)
+		if err != nil {
+			return cu.Result{}, false, errors.Wrapf(err, "error listing bindings for queue %s on vhost %s", queue, vhost)
+		}
+		for _, binding := range bindings {
+			if binding.Source == "" {
+				continue
+			}
+			saved := rabbitv1beta1.RabbitQueueMigrationBinding{Source: binding.Source, RoutingKey: binding.RoutingKey}
+			if len(binding.Arguments) != 0 {
+				raw, err := json.Marshal(binding.Arguments)
+				if err != nil {
+					return cu.Result{}, false, errors.Wrap(err, "error encoding binding arguments")
+				}
+				saved.Arguments = &runtime.RawExtension{Raw: raw}
+			}
+			migration.Bindings = append(migration.Bindings, saved)
+		}
+		// Copy the old settings so the temporary queue can hold anything the old queue could.
+		resp, err := rmqc.DeclareQueue(vhost, migration.TemporaryQueue, rabbithole.QueueSettings{Durable: existingQueue.Durable, Arguments: existingQueue.Arguments})
+		if err != nil {
+			return cu.Result{}, false, errors.Wrapf(err, "error creating temporary queue %s on vhost %s", migration.TemporaryQueue, vhost)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return cu.Result{}, false, errors.Errorf("unable to create temporary queue %s on vhost %s, got response code %v", migration.TemporaryQueue, vhost, resp.StatusCode)
+		}
+		err = bindMigrationQueue(rmqc, vhost, migration.TemporaryQueue, migration.Bindings)
+		if err != nil {
+			return cu.Result{}, false, err
+		}
+		resp, err = rmqc.DeclareShovel(vhost, shovel, rabbithole.ShovelDefinition{
+			SourceURI:        localURI,
+			SourceQueue:      queue,
+			DestinationURI:   localURI,
+			DestinationQueue: migration.TemporaryQueue,
+			AckMode:          "on-confirm",
+		})
+		if err != nil {
+			return cu.Result{}, false, errors.Wrapf(err, "error creating migration shovel %s on vhost %s", shovel, vhost)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return cu.Result{}, false, errors.Errorf("unable to create migration shovel %s on vhost %s, got response code %v", shovel, vhost, resp.StatusCode)
+		}
+		// Record the saved bindings before unbinding the old queue below, so a failure there can't lose them.
+		obj.Status.Migration = migration
+		ctx.Events.Eventf(obj, "Normal", "MigrationStarted", "RabbitMQ queue %s on vhost %s migrating through %s", queue, vhost, migration.TemporaryQueue)
+	}
+
+	switch migration.Phase {
+	case "MovingToTemporary":
+		// The temporary queue is bound by now, so stop routing to the old one too or messages would be duplicated.
+		err := unbindMigrationQueue(rmqc, vhost, queue)
+		if err != nil {
+			return cu.Result{}, false, err
+		}
+		current, err := rmqc.GetQueue(vhost, queue)
+		if err != nil {
+			return cu.Result{}, false, errors.Wrapf(err, "error getting queue %s on vhost %s", queue, vhost)
+		}
+		if current.Messages != 0 {
+			return migrationPending(ctx, obj, current.Messages), false, nil
+		}
+		// The message count lags behind so IfEmpty is what actually protects any stragglers.
+		_, err = rmqc.DeleteQueue(vhost, queue, rabbithole.QueueDeleteOptions{IfEmpty: true})
+		if err != nil {
+			rabbitErr, ok := err.(rabbithole.ErrorResponse)
+			if ok && rabbitErr.StatusCode == 400 {
+				return migrationPending(ctx, obj, current.Messages), false, nil
+			}
+			return cu.Result{}, false, errors.Wrapf(err, "error deleting queue %s on vhost %s", queue, vhost)
+		}
+		_, err = rmqc.DeleteShovel(vhost, shovel)
+		if err != nil {
+			return cu.Result{}, false, errors.Wrapf(err, "error deleting migration shovel %s on vhost %s", shovel, vhost)
+		}
+		migration.Phase = "Redeclaring"
+		fallthrough
+	case "Redeclaring":
+		_, err := rmqc.DeclareQueue(vhost, queue, settings)
+		if err != nil {
+			return cu.Result{}, false, errors.Wrapf(err, "error recreating queue %s on vhost %s", queue, vhost)
+		}
+		err = bindMigrationQueue(rmqc, vhost, queue, migration.Bindings)
+		if err != nil {
+			return cu.Result{}, false, err
+		}
+		// Unbind the temporary queue right away so it can drain and nothing is routed to both.
+		err = unbindMigrationQueue(rmqc, vhost, migration.TemporaryQueue)
+		if err != nil {
+			return cu.Result{}, false, err
+		}
+		resp, err := rmqc.DeclareShovel(vhost, shovel, rabbithole.ShovelDefinition{
+			SourceURI:        localURI,
+			SourceQueue:      migration.TemporaryQueue,
+			DestinationURI:   localURI,
+			DestinationQueue: queue,
+			AckMode:          "on-confirm",
+		})
+		if err != nil {
+			return cu.Result{}, false, errors.Wrapf(err, "error creating migration shovel %s on vhost %s", shovel, vhost)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return cu.Result{}, false, errors.Errorf("unable to create migration shovel %s on vhost %s, got response code %v", shovel, vhost, resp.StatusCode)
+		}
+		ctx.Events.Eventf(obj, "Normal", "QueueCreated", "RabbitMQ queue %s on vhost %s created", queue, vhost)
+		migration.Phase = "MovingBack"
+		fallthrough
+	case "MovingBack":
+		temporary, err := rmqc.GetQueue(vhost, migration.TemporaryQueue)
+		if err != nil {
+			return cu.Result{}, false, errors.Wrapf(err, "error getting temporary queue %s on vhost %s", migration.TemporaryQueue, vhost)
+		}
+		if temporary.Messages != 0 {
+			return migrationPending(ctx, obj, temporary.Messages), false, nil
+		}
+		_, err = rmqc.DeleteQueue(vhost, migration.TemporaryQueue, rabbithole.QueueDeleteOptions{IfEmpty: true})
+		if err != nil {
+			rabbitErr, ok := err.(rabbithole.ErrorResponse)
+			if ok && rabbitErr.StatusCode == 400 {
+				return migrationPending(ctx, obj, temporary.Messages), false, nil
+			}
+			return cu.Result{}, false, errors.Wrapf(err, "error deleting temporary queue %s on vhost %s", migration.TemporaryQueue, vhost)
+		}
+		_, err = rmqc.DeleteShovel(vhost, shovel)
+		if err != nil {
+			return cu.Result{}, false, errors.Wrapf(err, "error deleting migration shovel %s on vhost %s", shovel, vhost)
+		}
+	default:
+		return cu.Result{}, false, errors.Errorf("unknown migration phase %s", migration.Phase)
 	}
+
+	obj.Status.Migration = nil
+	ctx.Events.Eventf(obj, "Normal", "QueueMigrated", "RabbitMQ queue %s on vhost %s migrated", queue, vhost)
 	return cu.Result{}, true, nil
 }
+
+// Bind a queue to the exchanges recorded at the start of a migration.
+func bindMigrationQueue(rmqc rabbitManager, vhost, queue string, bindings []rabbitv1beta1.RabbitQueueMigrationBinding) error {
+	for _, binding := range bindings {
+		info := rabbithole.BindingInfo{Source: binding.Source, Destination: queue, DestinationType: "queue", RoutingKey: binding.RoutingKey}
+		if binding.Arguments != nil {
+			err := json.Unmarshal(binding.Arguments.Raw, &info.Arguments)
+			if err != nil {
+				return errors.Wrap(err, "error parsing binding arguments")
+			}
+		}
+		_, err := rmqc.DeclareBinding(vhost, info)
+		if err != nil {
+			return errors.Wrapf(err, "error binding queue %s on vhost %s to exchange %s", queue, vhost, binding.Source)
+		}
+	}
+	return nil
+}
+
+// Remove every binding for a queue except the implicit one from the default exchange.
+func unbindMigrationQueue(rmqc rabbitManager, vhost, queue string) error {
+	bindings, err := rmqc.ListQueueBindings(vhost, queue)
+	if err != nil {
+		return errors.Wrapf(err, "error listing bindings for queue %s on vhost %s", queue, vhost)
+	}
+	for _, binding := range bindings {
+		if binding.Source == "" {
+			continue
+		}
+		_, err = rmqc.DeleteBinding(vhost, binding)
+		if err != nil {
+			return errors.Wrapf(err, "error unbinding queue %s on vhost %s from exchange %s", queue, vhost, binding.Source)
+		}
+	}
+	return nil
+}
+
+// Find another RabbitQueue that already manages the same queue on the same broker, returning its namespace/name.
+func queueClaimant(ctx *cu.Context, obj *rabbitv1beta1.RabbitQueue, vhost, queue string) (string, error) {
+	queues := &rabbitv1beta1.RabbitQueueList{}
+	err := ctx.Client.List(ctx, queues)
+	if err != nil {
+		return "", errors.Wrap(err, "error listing queues")
+	}
+	for _, other := range queues.Items {
+		if other.UID == obj.UID || !other.Status.Owned || other.Spec.Vhost != vhost || other.Status.QueueName != queue {
+			continue
+		}
+		if sameBroker(&other.Spec.Connection, &obj.Spec.Connection) {
+			return other.Namespace + "/" + other.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// Check if two connections point at the same broker, ignoring the credentials.
+func sameBroker(a, b *rabbitv1beta1.RabbitConnection) bool {
+	if (a.ClusterRef == nil) != (b.ClusterRef == nil) || (a.ClusterRef != nil && a.ClusterRef.Name != b.ClusterRef.Name) {
+		return false
+	}
+	return a.Protocol == b.Protocol && a.Host == b.Host && a.Port == b.Port
+}
+
+// Report an in-progress migration and check back soon.
+func migrationPending(ctx *cu.Context, obj *rabbitv1beta1.RabbitQueue, messages int) cu.Result {
+	// Only reachable once the name has resolved.
+	queue, _ := obj.ResolveQueueName()
+	ctx.Conditions.SetfFalse("QueueReady", "Migrating", "RabbitMQ queue %s on vhost %s migration is %s with %d messages remaining", queue, obj.Spec.Vhost, obj.Status.Migration.Phase, messages)
+	return cu.Result{RequeueAfter: 10 * time.Second}
+}
+
+// Merge the typed queue fields into the untyped arguments.
+func queueArguments(spec *rabbitv1beta1.RabbitQueueSpec) (map[string]interface{}, error) {
+	args := map[string]interface{}{}
+	if spec.Arguments != nil {
+		err := json.Unmarshal(spec.Arguments.Raw, &args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Always explicit so a vhost default queue type doesn't apply.
+	if spec.Type != "" {
+		args["x-queue-type"] = spec.Type
+	}
+	if spec.Quorum != nil {
+		if spec.Quorum.InitialGroupSize != nil {
+			args["x-quorum-initial-group-size"] = *spec.Quorum.InitialGroupSize
+		}
+		if spec.Quorum.DeliveryLimit != nil {
+			args["x-delivery-limit"] = *spec.Quorum.DeliveryLimit
+		}
+	}
+	if spec.DeadLetter != nil && spec.DeadLetter.Enabled {
+		args["x-dead-letter-exchange"] = spec.DeadLetter.ExchangeName
+		args["x-dead-letter-routing-key"] = spec.DeadLetter.RoutingKey
+		if spec.DeadLetter.MaxRetries != nil {
+			args["x-delivery-limit"] = *spec.DeadLetter.MaxRetries
+		}
+	}
+	if spec.Stream != nil {
+		if spec.Stream.MaxAge != "" {
+			args["x-max-age"] = spec.Stream.MaxAge
+		}
+		if spec.Stream.MaxSegmentSize != nil {
+			args["x-stream-max-segment-size-bytes"] = *spec.Stream.MaxSegmentSize
+		}
+	}
+
+	// Round-trip through JSON so numbers compare equal to what the management API returns.
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	normalized := map[string]interface{}{}
+	err = json.Unmarshal(encoded, &normalized)
+	if err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// Work out the type of a queue from its arguments, queues declared without x-queue-type are classic.
+func queueType(args map[string]interface{}) string {
+	queueType, ok := args["x-queue-type"].(string)
+	if !ok || queueType == "" {
+		return "classic"
+	}
+	return queueType
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"reflect"
+
+	cu "github.com/coderanger/controller-utils"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	"github.com/pkg/errors"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+// Declare the dead-letter exchange and queue and bind them together. Existing ones are left alone rather than
+// redeclared, since the exchange might be shared and redeclaring with different settings fails. A changed ttl on the
+// queue is reported as drift, since it can't be changed without recreating the queue.
+func (comp *queueComponent) declareDeadLetter(ctx *cu.Context, rmqc rabbitManager, obj *rabbitv1beta1.RabbitQueue) error {
+	deadLetter := obj.Spec.DeadLetter
+	if deadLetter == nil || !deadLetter.Enabled {
+		return nil
+	}
+	vhost := obj.Spec.Vhost
+
+	_, err := rmqc.GetExchange(vhost, deadLetter.ExchangeName)
+	if err != nil {
+		rabbitErr, ok := err.(rabbithole.ErrorResponse)
+		if !ok || rabbitErr.StatusCode != 404 {
+			return errors.Wrapf(err, "error getting dead-letter exchange %s on vhost %s", deadLetter.ExchangeName, vhost)
+		}
+		exchangeSettings := exchangeSettings{}
+		exchangeSettings.Type = "direct"
+		exchangeSettings.Durable = true
+		_, err := rmqc.DeclareExchange(vhost, deadLetter.ExchangeName, exchangeSettings)
+		if err != nil {
+			return errors.Wrapf(err, "error declaring dead-letter exchange %s on vhost %s", deadLetter.ExchangeName, vhost)
+		}
+		obj.Status.DeadLetterExchangeCreated = true
+	}
+
+	args := map[string]interface{}{ownerArgument: string(obj.UID)}
+	if deadLetter.TTL != nil {
+		// Same type the management API hands back, so it compares cleanly below.
+		args["x-message-ttl"] = float64(*deadLetter.TTL)
+	}
+	existingQueue, err := rmqc.GetQueue(vhost, deadLetter.QueueName)
+	if err != nil {
+		rabbitErr, ok := err.(rabbithole.ErrorResponse)
+		if !ok || rabbitErr.StatusCode != 404 {
+			return errors.Wrapf(err, "error getting dead-letter queue %s on vhost %s", deadLetter.QueueName, vhost)
+		}
+		_, err := rmqc.DeclareQueue(vhost, deadLetter.QueueName, rabbithole.QueueSettings{Durable: true, Arguments: args})
+		if err != nil {
+			return errors.Wrapf(err, "error declaring dead-letter queue %s on vhost %s", deadLetter.QueueName, vhost)
+		}
+		ctx.Events.Eventf(obj, "Normal", "DeadLetterCreated", "RabbitMQ dead-letter queue %s on vhost %s created", deadLetter.QueueName, vhost)
+		ctx.Conditions.SetfFalse("DeadLetterDrifted", "NoDrift", "RabbitMQ dead-letter queue %s on vhost %s ttl matches", deadLetter.QueueName, vhost)
+	} else if !reflect.DeepEqual(existingQueue.Arguments["x-message-ttl"], args["x-message-ttl"]) {
+		ctx.Conditions.SetfTrue("DeadLetterDrifted", "TTLDrifted", "RabbitMQ dead-letter queue %s on vhost %s ttl is %v, expecting %v", deadLetter.QueueName, vhost, existingQueue.Arguments["x-message-ttl"], args["x-message-ttl"])
+	} else {
+		ctx.Conditions.SetfFalse("DeadLetterDrifted", "NoDrift", "RabbitMQ dead-letter queue %s on vhost %s ttl matches", deadLetter.QueueName, vhost)
+	}
+
+	bindings, err := rmqc.ListQueueBindingsBetween(vhost, deadLetter.ExchangeName, deadLetter.QueueName)
+	if err != nil {
+		return errors.Wrapf(err, "error listing bindings for dead-letter queue %s on vhost %s", deadLetter.QueueName, vhost)
+	}
+	for _, binding := range bindings {
+		if binding.RoutingKey == deadLetter.RoutingKey {
+			return nil
+		}
+	}
+	_, err = rmqc.DeclareBinding(vhost, rabbithole.BindingInfo{
+		Source:          deadLetter.ExchangeName,
+		Destination:     deadLetter.QueueName,
+		DestinationType: "queue",
+		RoutingKey:      deadLetter.RoutingKey,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error binding dead-letter queue %s on vhost %s", deadLetter.QueueName, vhost)
+	}
+	return nil
+}
+
+// Clean up the dead-letter queue and exchange after the main queue is gone, but only the parts this object created.
+// The queue carries the owner argument, the exchange is tracked in the status and left in place while anything else
+// is still bound to it.
+func (comp *queueComponent) deleteDeadLetter(ctx *cu.Context, rmqc rabbitManager, obj *rabbitv1beta1.RabbitQueue, opts rabbithole.QueueDeleteOptions) (cu.Result, bool, error) {
+	deadLetter := obj.Spec.DeadLetter
+	if deadLetter == nil || !deadLetter.Enabled {
+		return cu.Result{}, true, nil
+	}
+	vhost := obj.Spec.Vhost
+
+	existingQueue, err := rmqc.GetQueue(vhost, deadLetter.QueueName)
+	if err != nil {
+		rabbitErr, ok := err.(rabbithole.ErrorResponse)
+		if !ok || rabbitErr.StatusCode != 404 {
+			return cu.Result{}, false, errors.Wrapf(err, "error getting dead-letter queue %s on vhost %s", deadLetter.QueueName, vhost)
+		}
+	} else if owner, _ := existingQueue.Arguments[ownerArgument].(string); owner == string(obj.UID) {
+		_, err := rmqc.DeleteQueue(vhost, deadLetter.QueueName, opts)
+		if err != nil {
+			rabbitErr, ok := err.(rabbithole.ErrorResponse)
+			if ok && opts.IfEmpty && rabbitErr.StatusCode == 400 {
+				return deletionBlocked(ctx, "QueueReady", "RabbitMQ dead-letter queue %s on vhost %s is not empty", deadLetter.QueueName, vhost), false, nil
+			}
+			return cu.Result{}, false, errors.Wrapf(err, "error deleting dead-letter queue %s on vhost %s", deadLetter.QueueName, vhost)
+		}
+	}
+
+	if !obj.Status.DeadLetterExchangeCreated {
+		return cu.Result{}, true, nil
+	}
+	bindings, err := rmqc.ListExchangeBindingsWithSource(vhost, deadLetter.ExchangeName)
+	if err != nil {
+		return cu.Result{}, false, errors.Wrapf(err, "error listing bindings for dead-letter exchange %s on vhost %s", deadLetter.ExchangeName, vhost)
+	}
+	if len(bindings) != 0 {
+		ctx.Events.Eventf(obj, "Normal", "DeadLetterRetained", "RabbitMQ dead-letter exchange %s on vhost %s is still in use, not deleting it", deadLetter.ExchangeName, vhost)
+		return cu.Result{}, true, nil
+	}
+	_, err = rmqc.DeleteExchange(vhost, deadLetter.ExchangeName)
+	if err != nil {
+		rabbitErr, ok := err.(rabbithole.ErrorResponse)
+		if !ok || rabbitErr.StatusCode != 404 {
+			return cu.Result{}, false, errors.Wrapf(err, "error deleting dead-letter exchange %s on vhost %s", deadLetter.ExchangeName, vhost)
+		}
+	}
+	return cu.Result{}, true, nil
+}
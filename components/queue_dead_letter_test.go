@@ -0,0 +1,179 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	cu "github.com/coderanger/controller-utils"
+	. "github.com/coderanger/controller-utils/tests/matchers"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("Queue component dead-lettering", func() {
+	var obj *rabbitv1beta1.RabbitQueue
+	var rabbit *fakeRabbitClient
+	var helper *cu.UnitHelper
+
+	BeforeEach(func() {
+		rabbit = newFakeRabbitClient()
+		comp := Queue()
+		comp.clientFactory = rabbit.Factory
+		ttl := 60000
+		obj = &rabbitv1beta1.RabbitQueue{
+			ObjectMeta: metav1.ObjectMeta{UID: "testing-uid"},
+			Spec: rabbitv1beta1.RabbitQueueSpec{
+				Vhost: "/",
+				DeadLetter: &rabbitv1beta1.RabbitDeadLetter{
+					Enabled: true,
+					TTL:     &ttl,
+				},
+				Connection: rabbitv1beta1.RabbitConnection{
+					Host:     "testhost",
+					Username: "testuser",
+				},
+			},
+		}
+		helper = suiteHelper.Setup(comp, obj)
+	})
+
+	It("creates the dead-letter topology", func() {
+		helper.MustReconcile()
+		Expect(rabbit.Exchanges["/"]).To(MatchAllKeys(Keys{
+			"testing.dlx": PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type":    Equal("direct"),
+				"Durable": BeTrue(),
+			})),
+		}))
+		Expect(rabbit.Queues["/"]).To(MatchAllKeys(Keys{
+			"testing": PointTo(MatchFields(IgnoreExtras, Fields{
+				"Arguments": MatchAllKeys(Keys{
+					ownerArgument:               Equal("testing-uid"),
+					"x-dead-letter-exchange":    Equal("testing.dlx"),
+					"x-dead-letter-routing-key": Equal("testing"),
+				}),
+			})),
+			"testing.dlq": PointTo(MatchFields(IgnoreExtras, Fields{
+				"Durable": BeTrue(),
+				"Arguments": MatchAllKeys(Keys{
+					ownerArgument:   Equal("testing-uid"),
+					"x-message-ttl": BeEquivalentTo(60000),
+				}),
+			})),
+		}))
+		Expect(rabbit.Bindings["/"]).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Source":          Equal("testing.dlx"),
+			"Destination":     Equal("testing.dlq"),
+			"DestinationType": Equal("queue"),
+			"RoutingKey":      Equal("testing"),
+		}))))
+		Expect(helper.Events).To(Receive(Equal("Normal DeadLetterCreated RabbitMQ dead-letter queue testing.dlq on vhost / created")))
+		Expect(helper.Events).To(Receive(Equal("Normal QueueCreated RabbitMQ queue testing on vhost / created")))
+	})
+
+	It("does not duplicate the binding", func() {
+		helper.MustReconcile()
+		helper.MustReconcile()
+		Expect(rabbit.Bindings["/"]).To(HaveLen(1))
+	})
+
+	It("reports a changed ttl as drift", func() {
+		helper.MustReconcile()
+		Expect(obj).To(HaveCondition("DeadLetterDrifted").WithStatus("False").WithReason("NoDrift"))
+		ttl := 120000
+		obj.Spec.DeadLetter.TTL = &ttl
+		helper.MustReconcile()
+		Expect(obj).To(HaveCondition("DeadLetterDrifted").WithStatus("True").WithReason("TTLDrifted"))
+		Expect(rabbit.Queues["/"]["testing.dlq"].Arguments).To(HaveKeyWithValue("x-message-ttl", BeEquivalentTo(60000)))
+	})
+
+	It("uses delivery-limit for max retries", func() {
+		maxRetries := 3
+		obj.Spec.Type = "quorum"
+		obj.Spec.DeadLetter.MaxRetries = &maxRetries
+		helper.MustReconcile()
+		Expect(rabbit.Queues["/"]["testing"].Arguments).To(HaveKeyWithValue("x-delivery-limit", BeEquivalentTo(3)))
+	})
+
+	It("cleans up the dead-letter topology", func() {
+		helper.MustReconcile()
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Queues["/"]).To(BeEmpty())
+		Expect(rabbit.Exchanges["/"]).To(BeEmpty())
+	})
+
+	It("does not delete a dead-letter exchange it did not create", func() {
+		rabbit.Exchanges["/"] = map[string]*rabbithole.ExchangeInfo{
+			"testing.dlx": {Name: "testing.dlx", Vhost: "/", Type: "topic", Durable: true},
+		}
+		helper.MustReconcile()
+		Expect(obj.Status.DeadLetterExchangeCreated).To(BeFalse())
+		Expect(rabbit.Exchanges["/"]["testing.dlx"].Type).To(Equal("topic"))
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Queues["/"]).To(BeEmpty())
+		Expect(rabbit.Exchanges["/"]).To(HaveKey("testing.dlx"))
+	})
+
+	It("does not delete a dead-letter queue it did not create", func() {
+		rabbit.Queues["/"] = map[string]*rabbithole.QueueInfo{
+			"testing.dlq": {Name: "testing.dlq", Vhost: "/", Durable: true, Arguments: map[string]interface{}{"x-message-ttl": float64(60000)}},
+		}
+		helper.MustReconcile()
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Queues["/"]).To(MatchAllKeys(Keys{
+			"testing.dlq": Not(BeNil()),
+		}))
+		// Still bound to the queue that was left behind.
+		Expect(rabbit.Exchanges["/"]).To(HaveKey("testing.dlx"))
+		Expect(helper.Events).To(Receive(Equal("Normal QueueCreated RabbitMQ queue testing on vhost / created")))
+		Expect(helper.Events).To(Receive(Equal("Normal DeadLetterRetained RabbitMQ dead-letter exchange testing.dlx on vhost / is still in use, not deleting it")))
+	})
+
+	It("keeps a dead-letter exchange another queue is bound to", func() {
+		helper.MustReconcile()
+		rabbit.Bindings["/"] = append(rabbit.Bindings["/"], &rabbithole.BindingInfo{
+			Source:          "testing.dlx",
+			Destination:     "other.dlq",
+			DestinationType: "queue",
+			RoutingKey:      "other",
+		})
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Queues["/"]).To(BeEmpty())
+		Expect(rabbit.Exchanges["/"]).To(HaveKey("testing.dlx"))
+	})
+
+	It("blocks deleting a non-empty dead-letter queue with the DeleteIfEmpty policy", func() {
+		obj.Spec.DeletionPolicy = "DeleteIfEmpty"
+		helper.MustReconcile()
+		rabbit.Queues["/"]["testing.dlq"].Messages = 5
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeFalse())
+		Expect(rabbit.Queues["/"]).To(MatchAllKeys(Keys{
+			"testing.dlq": Not(BeNil()),
+		}))
+		Expect(rabbit.Exchanges["/"]).To(HaveKey("testing.dlx"))
+		Expect(obj).To(HaveCondition("QueueReady").WithStatus("False").WithReason("DeletionBlocked"))
+	})
+})
@@ -0,0 +1,169 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"sort"
+	"time"
+
+	cu "github.com/coderanger/controller-utils"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	"github.com/pkg/errors"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+type queueMembersComponent struct {
+	clientFactory rabbitClientFactory
+}
+
+func QueueMembers() *queueMembersComponent {
+	return &queueMembersComponent{clientFactory: rabbitholeClientFactory}
+}
+
+func (_ *queueMembersComponent) GetReadyCondition() string {
+	return "MembersReady"
+}
+
+// Move a quorum queue one replica at a time towards the target number of members. Members on nodes which have left
+// the cluster are dropped first, then replicas are added on running nodes or removed from the non-leader members.
+// Stopped nodes still count towards the cluster size so a rolling restart doesn't shrink anything.
+func (comp *queueMembersComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitQueue)
+	if obj.Spec.Type != "quorum" || obj.Spec.Quorum == nil || obj.Spec.Quorum.TargetMembers == nil {
+		obj.Status.Members = nil
+		return cu.Result{}, nil
+	}
+	ctx.Conditions.SetUnknown("MembersReady", "Unknown")
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	queue, err := obj.ResolveQueueName()
+	if err != nil {
+		return cu.Result{}, err
+	}
+	vhost := obj.Spec.Vhost
+
+	info, err := rmqc.GetQueueMembers(vhost, queue)
+	if err != nil {
+		rabbitErr, ok := err.(rabbithole.ErrorResponse)
+		if ok && rabbitErr.StatusCode == 404 {
+			// Nothing to do until the queue component creates it.
+			return cu.Result{}, nil
+		}
+		return cu.Result{}, errors.Wrapf(err, "error getting members of queue %s on vhost %s", queue, vhost)
+	}
+	members := append([]string{}, info.Members...)
+	sort.Strings(members)
+	obj.Status.Members = members
+
+	nodes, err := rmqc.ListNodes()
+	if err != nil {
+		return cu.Result{}, errors.Wrap(err, "error listing nodes")
+	}
+	clusterNodes := map[string]bool{}
+	runningNodes := []string{}
+	for _, node := range nodes {
+		clusterNodes[node.Name] = node.IsRunning
+		if node.IsRunning {
+			runningNodes = append(runningNodes, node.Name)
+		}
+	}
+	sort.Strings(runningNodes)
+	isMember := map[string]bool{}
+	for _, member := range members {
+		isMember[member] = true
+	}
+
+	target := *obj.Spec.Quorum.TargetMembers
+	if target > len(nodes) {
+		target = len(nodes)
+	}
+
+	// Pick what to change, if anything.
+	var addNode, removeNode string
+	for _, member := range members {
+		_, ok := clusterNodes[member]
+		if !ok {
+			removeNode = member
+			break
+		}
+	}
+	if removeNode == "" && len(members) < target {
+		for _, node := range runningNodes {
+			if !isMember[node] {
+				addNode = node
+				break
+			}
+		}
+	}
+	if removeNode == "" && len(members) > target {
+		// Prefer stopped nodes, and never take out the leader.
+		for i := len(members) - 1; i >= 0; i-- {
+			member := members[i]
+			if member == info.Leader {
+				continue
+			}
+			if removeNode == "" || !clusterNodes[member] {
+				removeNode = member
+			}
+		}
+	}
+
+	if addNode != "" {
+		_, err = rmqc.AddQueueMember(vhost, queue, addNode)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error adding member %s to queue %s on vhost %s", addNode, queue, vhost)
+		}
+		obj.Status.Members = append(obj.Status.Members, addNode)
+		sort.Strings(obj.Status.Members)
+		ctx.Events.Eventf(obj, "Normal", "MemberAdded", "RabbitMQ queue %s on vhost %s grown onto %s", queue, vhost, addNode)
+		ctx.Conditions.SetfFalse("MembersReady", "Growing", "RabbitMQ queue %s on vhost %s has %d of %d members", queue, vhost, len(obj.Status.Members), target)
+		return cu.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+	if removeNode != "" {
+		_, err = rmqc.DeleteQueueMember(vhost, queue, removeNode)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error removing member %s from queue %s on vhost %s", removeNode, queue, vhost)
+		}
+		remaining := []string{}
+		for _, member := range obj.Status.Members {
+			if member != removeNode {
+				remaining = append(remaining, member)
+			}
+		}
+		obj.Status.Members = remaining
+		ctx.Events.Eventf(obj, "Normal", "MemberRemoved", "RabbitMQ queue %s on vhost %s shrunk off %s", queue, vhost, removeNode)
+		ctx.Conditions.SetfFalse("MembersReady", "Shrinking", "RabbitMQ queue %s on vhost %s has %d of %d members", queue, vhost, len(obj.Status.Members), target)
+		return cu.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	switch {
+	case len(members) < target:
+		ctx.Conditions.SetfFalse("MembersReady", "WaitingForNodes", "RabbitMQ queue %s on vhost %s has %d of %d members, waiting for nodes to be running", queue, vhost, len(members), target)
+	case target < *obj.Spec.Quorum.TargetMembers:
+		ctx.Conditions.SetfFalse("MembersReady", "NotEnoughNodes", "RabbitMQ queue %s on vhost %s has %d members, only %d nodes are in the cluster for a target of %d", queue, vhost, len(members), len(nodes), *obj.Spec.Quorum.TargetMembers)
+	default:
+		ctx.Conditions.SetfTrue("MembersReady", "MembersAtTarget", "RabbitMQ queue %s on vhost %s has %d members", queue, vhost, len(members))
+	}
+	// Check back regularly to catch nodes joining or leaving the cluster.
+	return cu.Result{RequeueAfter: time.Minute}, nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	cu "github.com/coderanger/controller-utils"
+	. "github.com/coderanger/controller-utils/tests/matchers"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("QueueMembers component", func() {
+	var obj *rabbitv1beta1.RabbitQueue
+	var rabbit *fakeRabbitClient
+	var helper *cu.UnitHelper
+
+	BeforeEach(func() {
+		rabbit = newFakeRabbitClient()
+		rabbit.Nodes = []rabbithole.NodeInfo{
+			{Name: "rabbit@rabbitmq-0", IsRunning: true},
+			{Name: "rabbit@rabbitmq-1", IsRunning: true},
+			{Name: "rabbit@rabbitmq-2", IsRunning: true},
+		}
+		rabbit.QueueMembers = map[string]map[string]*queueMembers{
+			"/": {
+				"testing": {
+					Leader:  "rabbit@rabbitmq-0",
+					Members: []string{"rabbit@rabbitmq-0"},
+				},
+			},
+		}
+		comp := QueueMembers()
+		comp.clientFactory = rabbit.Factory
+		targetMembers := 3
+		obj = &rabbitv1beta1.RabbitQueue{
+			Spec: rabbitv1beta1.RabbitQueueSpec{
+				Vhost: "/",
+				Type:  "quorum",
+				Quorum: &rabbitv1beta1.RabbitQuorumQueue{
+					TargetMembers: &targetMembers,
+				},
+				Connection: rabbitv1beta1.RabbitConnection{
+					Host:     "testhost",
+					Username: "testuser",
+				},
+			},
+		}
+		helper = suiteHelper.Setup(comp, obj)
+	})
+
+	It("grows a queue one member at a time", func() {
+		helper.MustReconcile()
+		Expect(rabbit.QueueMembers["/"]["testing"].Members).To(ConsistOf("rabbit@rabbitmq-0", "rabbit@rabbitmq-1"))
+		Expect(obj.Status.Members).To(Equal([]string{"rabbit@rabbitmq-0", "rabbit@rabbitmq-1"}))
+		Expect(obj).To(HaveCondition("MembersReady").WithStatus("False").WithReason("Growing"))
+		Expect(helper.Events).To(Receive(Equal("Normal MemberAdded RabbitMQ queue testing on vhost / grown onto rabbit@rabbitmq-1")))
+
+		helper.MustReconcile()
+		helper.MustReconcile()
+		Expect(rabbit.QueueMembers["/"]["testing"].Members).To(ConsistOf("rabbit@rabbitmq-0", "rabbit@rabbitmq-1", "rabbit@rabbitmq-2"))
+		Expect(obj).To(HaveCondition("MembersReady").WithStatus("True").WithReason("MembersAtTarget"))
+	})
+
+	It("shrinks a queue without removing the leader", func() {
+		targetMembers := 1
+		obj.Spec.Quorum.TargetMembers = &targetMembers
+		rabbit.QueueMembers["/"]["testing"].Leader = "rabbit@rabbitmq-2"
+		rabbit.QueueMembers["/"]["testing"].Members = []string{"rabbit@rabbitmq-0", "rabbit@rabbitmq-1", "rabbit@rabbitmq-2"}
+		helper.MustReconcile()
+		helper.MustReconcile()
+		Expect(rabbit.QueueMembers["/"]["testing"].Members).To(ConsistOf("rabbit@rabbitmq-2"))
+		Expect(helper.Events).To(Receive(Equal("Normal MemberRemoved RabbitMQ queue testing on vhost / shrunk off rabbit@rabbitmq-1")))
+		Expect(helper.Events).To(Receive(Equal("Normal MemberRemoved RabbitMQ queue testing on vhost / shrunk off rabbit@rabbitmq-0")))
+	})
+
+	It("replaces members on nodes that left the cluster", func() {
+		rabbit.QueueMembers["/"]["testing"].Members = []string{"rabbit@rabbitmq-0", "rabbit@old-1", "rabbit@rabbitmq-2"}
+		helper.MustReconcile()
+		Expect(rabbit.QueueMembers["/"]["testing"].Members).To(ConsistOf("rabbit@rabbitmq-0", "rabbit@rabbitmq-2"))
+		Expect(helper.Events).To(Receive(Equal("Normal MemberRemoved RabbitMQ queue testing on vhost / shrunk off rabbit@old-1")))
+		helper.MustReconcile()
+		Expect(rabbit.QueueMembers["/"]["testing"].Members).To(ConsistOf("rabbit@rabbitmq-0", "rabbit@rabbitmq-1", "rabbit@rabbitmq-2"))
+	})
+
+	It("does not shrink off stopped nodes", func() {
+		rabbit.Nodes[1].IsRunning = false
+		rabbit.QueueMembers["/"]["testing"].Members = []string{"rabbit@rabbitmq-0", "rabbit@rabbitmq-1", "rabbit@rabbitmq-2"}
+		helper.MustReconcile()
+		Expect(rabbit.QueueMembers["/"]["testing"].Members).To(HaveLen(3))
+		Expect(obj).To(HaveCondition("MembersReady").WithStatus("True").WithReason("MembersAtTarget"))
+	})
+
+	It("reports when the cluster is too small", func() {
+		targetMembers := 5
+		obj.Spec.Quorum.TargetMembers = &targetMembers
+		rabbit.QueueMembers["/"]["testing"].Members = []string{"rabbit@rabbitmq-0", "rabbit@rabbitmq-1", "rabbit@rabbitmq-2"}
+		helper.MustReconcile()
+		Expect(obj).To(HaveCondition("MembersReady").WithStatus("False").WithReason("NotEnoughNodes"))
+	})
+
+	It("leaves queues without targetMembers alone", func() {
+		obj.Spec.Quorum.TargetMembers = nil
+		helper.MustReconcile()
+		Expect(rabbit.QueueMembers["/"]["testing"].Members).To(HaveLen(1))
+		Expect(obj.Status.Members).To(BeNil())
+	})
+})
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"time"
+
+	cu "github.com/coderanger/controller-utils"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+type queueStatsComponent struct {
+	clientFactory rabbitClientFactory
+}
+
+func QueueStats() *queueStatsComponent {
+	return &queueStatsComponent{clientFactory: rabbitholeClientFactory}
+}
+
+func (comp *queueStatsComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitQueue)
+
+	interval := time.Minute
+	if obj.Spec.StatsInterval != nil {
+		interval = obj.Spec.StatsInterval.Duration
+	}
+
+	// Every status change triggers another reconcile, so leave the stats alone until they are due a refresh.
+	if obj.Status.Stats != nil {
+		if interval == 0 {
+			return cu.Result{}, nil
+		}
+		remaining := time.Until(obj.Status.Stats.LastUpdated.Add(interval))
+		if remaining > 0 {
+			return cu.Result{RequeueAfter: remaining}, nil
+		}
+	}
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	queue, err := obj.ResolveQueueName()
+	if err != nil {
+		return cu.Result{}, err
+	}
+	vhost := obj.Spec.Vhost
+
+	info, err := rmqc.GetQueue(vhost, queue)
+	if err != nil {
+		rabbitErr, ok := err.(rabbithole.ErrorResponse)
+		if ok && rabbitErr.StatusCode == 404 {
+			// Probably mid-migration, the queue component will deal with it.
+			obj.Status.Stats = nil
+			return cu.Result{RequeueAfter: interval}, nil
+		}
+		return cu.Result{}, errors.Wrapf(err, "error getting queue %s on vhost %s", queue, vhost)
+	}
+
+	obj.Status.Stats = &rabbitv1beta1.RabbitQueueStats{
+		MessagesReady:          info.MessagesReady,
+		MessagesUnacknowledged: info.MessagesUnacknowledged,
+		Consumers:              info.Consumers,
+		Memory:                 info.Memory,
+		State:                  info.Status,
+		Node:                   info.Node,
+		Policy:                 info.Policy,
+		LastUpdated:            metav1.Now(),
+	}
+	return cu.Result{RequeueAfter: interval}, nil
+}
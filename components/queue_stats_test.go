@@ -0,0 +1,161 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"time"
+
+	cu "github.com/coderanger/controller-utils"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("QueueStats component", func() {
+	var obj *rabbitv1beta1.RabbitQueue
+	var rabbit *fakeRabbitClient
+	var helper *cu.UnitHelper
+
+	BeforeEach(func() {
+		rabbit = newFakeRabbitClient()
+		comp := QueueStats()
+		comp.clientFactory = rabbit.Factory
+		obj = &rabbitv1beta1.RabbitQueue{
+			Spec: rabbitv1beta1.RabbitQueueSpec{
+				Vhost: "/",
+				Connection: rabbitv1beta1.RabbitConnection{
+					Host:     "testhost",
+					Username: "testuser",
+				},
+			},
+		}
+		helper = suiteHelper.Setup(comp, obj)
+	})
+
+	It("records queue statistics", func() {
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:                   "testing",
+					Vhost:                  "/",
+					Node:                   "rabbit@rabbitmq-0",
+					Status:                 "running",
+					Memory:                 14000,
+					Consumers:              2,
+					Policy:                 "ha",
+					MessagesReady:          10,
+					MessagesUnacknowledged: 3,
+				},
+			},
+		}
+		res := helper.MustReconcile()
+		Expect(res.RequeueAfter).To(Equal(time.Minute))
+		Expect(obj.Status.Stats).To(PointTo(MatchFields(IgnoreExtras, Fields{
+			"MessagesReady":          Equal(10),
+			"MessagesUnacknowledged": Equal(3),
+			"Consumers":              Equal(2),
+			"Memory":                 Equal(int64(14000)),
+			"State":                  Equal("running"),
+			"Node":                   Equal("rabbit@rabbitmq-0"),
+			"Policy":                 Equal("ha"),
+		})))
+		Expect(obj.Status.Stats.LastUpdated.IsZero()).To(BeFalse())
+	})
+
+	It("uses the configured refresh interval", func() {
+		obj.Spec.StatsInterval = &metav1.Duration{Duration: 10 * time.Second}
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+				},
+			},
+		}
+		res := helper.MustReconcile()
+		Expect(res.RequeueAfter).To(Equal(10 * time.Second))
+	})
+
+	It("does not refresh the statistics within the interval", func() {
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:          "testing",
+					Vhost:         "/",
+					MessagesReady: 10,
+				},
+			},
+		}
+		helper.MustReconcile()
+		stats := obj.Status.Stats.DeepCopy()
+
+		rabbit.Queues["/"]["testing"].MessagesReady = 20
+		res := helper.MustReconcile()
+		Expect(obj.Status.Stats).To(Equal(stats))
+		Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(res.RequeueAfter).To(BeNumerically("<=", time.Minute))
+	})
+
+	It("does not refresh the statistics with a zero interval", func() {
+		obj.Spec.StatsInterval = &metav1.Duration{}
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:          "testing",
+					Vhost:         "/",
+					MessagesReady: 10,
+				},
+			},
+		}
+		helper.MustReconcile()
+		stats := obj.Status.Stats.DeepCopy()
+
+		rabbit.Queues["/"]["testing"].MessagesReady = 20
+		res := helper.MustReconcile()
+		Expect(obj.Status.Stats).To(Equal(stats))
+		Expect(res.RequeueAfter).To(BeZero())
+	})
+
+	It("refreshes the statistics once the interval has passed", func() {
+		obj.Status.Stats = &rabbitv1beta1.RabbitQueueStats{
+			MessagesReady: 10,
+			LastUpdated:   metav1.NewTime(time.Now().Add(-2 * time.Minute)),
+		}
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:          "testing",
+					Vhost:         "/",
+					MessagesReady: 20,
+				},
+			},
+		}
+		res := helper.MustReconcile()
+		Expect(res.RequeueAfter).To(Equal(time.Minute))
+		Expect(obj.Status.Stats.MessagesReady).To(Equal(20))
+	})
+
+	It("clears the statistics when the queue does not exist", func() {
+		obj.Status.Stats = &rabbitv1beta1.RabbitQueueStats{MessagesReady: 10}
+		helper.MustReconcile()
+		Expect(obj.Status.Stats).To(BeNil())
+	})
+})
@@ -25,6 +25,8 @@ import (
 	. "github.com/onsi/gomega/gstruct"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
 )
 
@@ -38,6 +40,7 @@ var _ = Describe("Queue component", func() {
 		comp := Queue()
 		comp.clientFactory = rabbit.Factory
 		obj = &rabbitv1beta1.RabbitQueue{
+			ObjectMeta: metav1.ObjectMeta{UID: "testing-uid"},
 			Spec: rabbitv1beta1.RabbitQueueSpec{
 				Vhost: "/",
 				Connection: rabbitv1beta1.RabbitConnection{
@@ -45,6 +48,8 @@ var _ = Describe("Queue component", func() {
 					Username: "testuser",
 				},
 			},
+			// Most tests start from a queue this object already manages.
+			Status: rabbitv1beta1.RabbitQueueStatus{Owned: true},
 		}
 		helper = suiteHelper.Setup(comp, obj)
 	})
@@ -62,6 +67,17 @@ var _ = Describe("Queue component", func() {
 		Expect(obj).To(HaveCondition("QueueReady").WithStatus("True").WithReason("QueueExists"))
 	})
 
+	It("creates a queue from a name template and keeps the name", func() {
+		obj.Spec.QueueNameTemplate = "{{ .Namespace }}.{{ .Name }}"
+		helper.MustReconcile()
+		Expect(rabbit.Queues["/"]).To(HaveKey("default.testing"))
+		Expect(obj.Status.QueueName).To(Equal("default.testing"))
+
+		obj.Spec.QueueNameTemplate = "{{ .Name }}"
+		helper.MustReconcile()
+		Expect(rabbit.Queues["/"]).To(MatchAllKeys(Keys{"default.testing": Not(BeNil())}))
+	})
+
 	It("does not update an existing queue", func() {
 		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
 			"/": {
@@ -88,6 +104,7 @@ var _ = Describe("Queue component", func() {
 					"Name":    Equal("testing"),
 					"Durable": BeTrue(),
 					"Arguments": MatchAllKeys(Keys{
+						ownerArgument:    Equal("testing-uid"),
 						"x-max-priority": BeEquivalentTo(10),
 					}),
 				})),
@@ -120,10 +137,654 @@ var _ = Describe("Queue component", func() {
 					"Name":    Equal("testing"),
 					"Durable": BeTrue(),
 					"Arguments": MatchAllKeys(Keys{
+						ownerArgument:    Equal("testing-uid"),
 						"x-max-priority": BeEquivalentTo(10),
 					}),
 				})),
 			}),
 		}))
 	})
+
+	It("ignores extra arguments by default", func() {
+		obj.Spec.Arguments = &runtime.RawExtension{
+			Raw: []byte(`{"x-max-priority":10}`),
+		}
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+					Arguments: map[string]interface{}{
+						"x-max-priority": 10.0,
+						"x-message-ttl":  60000.0,
+					},
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Queues["/"]["testing"].Arguments).To(HaveKey("x-message-ttl"))
+		Expect(obj).To(HaveCondition("QueueDrifted").WithStatus("False").WithReason("NoDrift"))
+		Expect(helper.Events).ToNot(Receive())
+	})
+
+	It("reports extra arguments as drift in Exact mode", func() {
+		obj.Spec.ArgumentsMode = "Exact"
+		obj.Spec.Arguments = &runtime.RawExtension{
+			Raw: []byte(`{"x-max-priority":10}`),
+		}
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:     "testing",
+					Vhost:    "/",
+					Messages: 10,
+					Arguments: map[string]interface{}{
+						"x-max-priority": 5.0,
+						"x-message-ttl":  60000.0,
+						"x-max-length":   100.0,
+					},
+				},
+			},
+		}
+		_, err := helper.Reconcile()
+		Expect(err).To(HaveOccurred())
+		Expect(obj).To(HaveCondition("QueueDrifted").WithStatus("True").WithReason("ArgumentsDrifted"))
+		Expect(obj.Status.Conditions).To(ContainElement(MatchFields(IgnoreExtras, Fields{
+			"Type":    Equal("QueueDrifted"),
+			"Message": Equal("RabbitMQ queue testing on vhost / arguments differ: x-max-length, x-max-priority, x-message-ttl"),
+		})))
+	})
+
+	It("recreates an empty queue with extra arguments in Exact mode", func() {
+		obj.Spec.ArgumentsMode = "Exact"
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+					Arguments: map[string]interface{}{
+						"x-message-ttl": 60000.0,
+					},
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Queues["/"]["testing"].Arguments).To(Equal(map[string]interface{}{ownerArgument: "testing-uid"}))
+		Expect(helper.Events).To(Receive(Equal("Normal QueueCreated RabbitMQ queue testing on vhost / created")))
+		Expect(obj).To(HaveCondition("QueueDrifted").WithStatus("False").WithReason("NoDrift"))
+	})
+
+	It("refuses to manage an existing queue", func() {
+		obj.Status.Owned = false
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+				},
+			},
+		}
+		res := helper.MustReconcile()
+		Expect(res.SkipRemaining).To(BeTrue())
+		Expect(obj).To(HaveCondition("QueueReady").WithStatus("False").WithReason("AlreadyExists"))
+	})
+
+	It("keeps refusing an existing queue once the finalizer is added", func() {
+		obj.Status.Owned = false
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(obj.Status.OwnershipConflict).To(BeTrue())
+		obj.Finalizers = []string{queueFinalizer}
+		res := helper.MustReconcile()
+		Expect(res.SkipRemaining).To(BeTrue())
+		Expect(obj.Status.Owned).To(BeFalse())
+		Expect(obj).To(HaveCondition("QueueReady").WithStatus("False").WithReason("AlreadyExists"))
+	})
+
+	It("does not adopt an existing queue after a failed first reconcile", func() {
+		obj.Status.Owned = false
+		obj.Spec.Connection = rabbitv1beta1.RabbitConnection{ClusterRef: &rabbitv1beta1.RabbitClusterRef{Name: "missing"}}
+		_, err := helper.Reconcile()
+		Expect(err).To(HaveOccurred())
+		Expect(obj.Status.OwnershipConflict).To(BeTrue())
+
+		obj.Finalizers = []string{queueFinalizer}
+		obj.Spec.Connection = rabbitv1beta1.RabbitConnection{Host: "testhost", Username: "testuser"}
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+				},
+			},
+		}
+		res := helper.MustReconcile()
+		Expect(res.SkipRemaining).To(BeTrue())
+		Expect(obj.Status.Owned).To(BeFalse())
+		Expect(obj).To(HaveCondition("QueueReady").WithStatus("False").WithReason("AlreadyExists"))
+	})
+
+	It("treats a queue from before ownership tracking as owned", func() {
+		obj.Status.Owned = false
+		obj.Finalizers = []string{queueFinalizer}
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(obj.Status.Owned).To(BeTrue())
+		Expect(helper.Events).ToNot(Receive())
+		Expect(obj).To(HaveCondition("QueueReady").WithStatus("True").WithReason("QueueExists"))
+	})
+
+	It("adopts an existing queue", func() {
+		obj.Status.Owned = false
+		obj.Spec.Adopt = true
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(obj.Status.Owned).To(BeTrue())
+		Expect(helper.Events).To(Receive(Equal("Normal QueueAdopted RabbitMQ queue testing on vhost / adopted")))
+		Expect(obj).To(HaveCondition("QueueReady").WithStatus("True").WithReason("QueueExists"))
+	})
+
+	It("refuses to adopt a queue another object has adopted", func() {
+		obj.Status.Owned = false
+		obj.Spec.Adopt = true
+		helper.TestClient.Create(&rabbitv1beta1.RabbitQueue{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", UID: "other-uid"},
+			Spec: rabbitv1beta1.RabbitQueueSpec{
+				Vhost:      "/",
+				Connection: obj.Spec.Connection,
+			},
+			Status: rabbitv1beta1.RabbitQueueStatus{Owned: true, QueueName: "testing"},
+		})
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+				},
+			},
+		}
+		res := helper.MustReconcile()
+		Expect(res.SkipRemaining).To(BeTrue())
+		Expect(obj.Status.Owned).To(BeFalse())
+		Expect(obj).To(HaveCondition("QueueReady").WithStatus("False").WithReason("OwnedByOther"))
+		Expect(helper.Events).ToNot(Receive())
+	})
+
+	It("recognizes a queue it created from the owner argument", func() {
+		obj.Status.Owned = false
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:      "testing",
+					Vhost:     "/",
+					Arguments: map[string]interface{}{ownerArgument: "testing-uid"},
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(obj.Status.Owned).To(BeTrue())
+		Expect(helper.Events).ToNot(Receive())
+	})
+
+	It("refuses to adopt a queue owned by another object", func() {
+		obj.Status.Owned = false
+		obj.Spec.Adopt = true
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:      "testing",
+					Vhost:     "/",
+					Arguments: map[string]interface{}{ownerArgument: "other-uid"},
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(obj.Status.Owned).To(BeFalse())
+		Expect(obj).To(HaveCondition("QueueReady").WithStatus("False").WithReason("OwnedByOther"))
+	})
+
+	It("does not delete a queue it does not own", func() {
+		obj.Status.Owned = false
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+				},
+			},
+		}
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Queues["/"]).To(HaveKey("testing"))
+	})
+
+	It("purges a queue once per nonce", func() {
+		obj.Annotations = map[string]string{"rabbitmq.coderanger.net/purge": "incident-42"}
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:     "testing",
+					Vhost:    "/",
+					Messages: 10,
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Queues["/"]["testing"].Messages).To(Equal(0))
+		Expect(obj.Status.LastPurge).To(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Nonce":    Equal("incident-42"),
+			"Messages": Equal(10),
+		})))
+		Expect(obj.Status.LastPurge.Time.IsZero()).To(BeFalse())
+		Expect(helper.Events).To(Receive(Equal("Normal QueuePurged RabbitMQ queue testing on vhost / purged of 10 messages")))
+
+		// The same nonce again does nothing.
+		rabbit.Queues["/"]["testing"].Messages = 5
+		helper.MustReconcile()
+		Expect(rabbit.Queues["/"]["testing"].Messages).To(Equal(5))
+		Expect(obj.Status.LastPurge.Messages).To(Equal(10))
+		Expect(helper.Events).ToNot(Receive())
+	})
+
+	It("does not purge a queue it just created", func() {
+		obj.Annotations = map[string]string{"rabbitmq.coderanger.net/purge": "incident-42"}
+		helper.MustReconcile()
+		Expect(obj.Status.LastPurge).To(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Nonce":    Equal("incident-42"),
+			"Messages": Equal(0),
+		})))
+		Expect(helper.Events).To(Receive(Equal("Normal QueueCreated RabbitMQ queue testing on vhost / created")))
+
+		// Messages published since then are kept.
+		rabbit.Queues["/"]["testing"].Messages = 5
+		helper.MustReconcile()
+		Expect(rabbit.Queues["/"]["testing"].Messages).To(Equal(5))
+		Expect(helper.Events).ToNot(Receive())
+	})
+
+	It("does not purge without the annotation", func() {
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:     "testing",
+					Vhost:    "/",
+					Messages: 10,
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Queues["/"]["testing"].Messages).To(Equal(10))
+		Expect(obj.Status.LastPurge).To(BeNil())
+	})
+
+	It("creates a quorum queue", func() {
+		groupSize := 3
+		deliveryLimit := 5
+		obj.Spec.Type = "quorum"
+		obj.Spec.Quorum = &rabbitv1beta1.RabbitQuorumQueue{InitialGroupSize: &groupSize, DeliveryLimit: &deliveryLimit}
+		helper.MustReconcile()
+		Expect(rabbit.Queues).To(MatchAllKeys(Keys{
+			"/": MatchAllKeys(Keys{
+				"testing": PointTo(MatchFields(IgnoreExtras, Fields{
+					"Durable": BeTrue(),
+					"Arguments": MatchAllKeys(Keys{
+						ownerArgument:                 Equal("testing-uid"),
+						"x-queue-type":                Equal("quorum"),
+						"x-quorum-initial-group-size": BeEquivalentTo(3),
+						"x-delivery-limit":            BeEquivalentTo(5),
+					}),
+				})),
+			}),
+		}))
+	})
+
+	It("creates a classic queue with an explicit type", func() {
+		obj.Spec.Type = "classic"
+		helper.MustReconcile()
+		Expect(rabbit.Queues["/"]["testing"].Arguments).To(HaveKeyWithValue("x-queue-type", "classic"))
+	})
+
+	It("does not update an existing classic queue declared without a type", func() {
+		obj.Spec.Type = "classic"
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(helper.Events).ToNot(Receive())
+		Expect(obj).To(HaveCondition("QueueDrifted").WithStatus("False"))
+	})
+
+	It("creates a stream", func() {
+		maxSegmentSize := int64(100000000)
+		obj.Spec.Type = "stream"
+		obj.Spec.Stream = &rabbitv1beta1.RabbitStreamQueue{MaxAge: "7D", MaxSegmentSize: &maxSegmentSize}
+		helper.MustReconcile()
+		Expect(rabbit.Queues).To(MatchAllKeys(Keys{
+			"/": MatchAllKeys(Keys{
+				"testing": PointTo(MatchFields(IgnoreExtras, Fields{
+					"Arguments": MatchAllKeys(Keys{
+						ownerArgument:                     Equal("testing-uid"),
+						"x-queue-type":                    Equal("stream"),
+						"x-max-age":                       Equal("7D"),
+						"x-stream-max-segment-size-bytes": BeEquivalentTo(100000000),
+					}),
+				})),
+			}),
+		}))
+	})
+
+	It("does not update an existing quorum queue", func() {
+		groupSize := 3
+		obj.Spec.Type = "quorum"
+		obj.Spec.Quorum = &rabbitv1beta1.RabbitQuorumQueue{InitialGroupSize: &groupSize}
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:    "testing",
+					Vhost:   "/",
+					Durable: true,
+					Arguments: map[string]interface{}{
+						"x-queue-type":                "quorum",
+						"x-quorum-initial-group-size": float64(3),
+					},
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(helper.Events).ToNot(Receive())
+	})
+
+	It("recreates an empty queue with the wrong type", func() {
+		obj.Spec.Type = "quorum"
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:    "testing",
+					Vhost:   "/",
+					Durable: true,
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Queues["/"]["testing"].Arguments).To(HaveKeyWithValue("x-queue-type", "quorum"))
+		Expect(helper.Events).To(Receive(Equal("Normal QueueCreated RabbitMQ queue testing on vhost / created")))
+	})
+
+	It("reports a type mismatch on a non-empty queue", func() {
+		obj.Spec.Type = "quorum"
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:     "testing",
+					Vhost:    "/",
+					Durable:  true,
+					Messages: 10,
+				},
+			},
+		}
+		_, err := helper.Reconcile()
+		Expect(err).To(MatchError("queue settings do not match: Type currently classic expecting quorum"))
+		Expect(obj).To(HaveCondition("QueueReady").WithStatus("False").WithReason("QueueTypeMismatch"))
+	})
+
+	It("leaves a mismatched queue alone with the Never policy", func() {
+		d := true
+		obj.Spec.Durable = &d
+		obj.Spec.MigrationPolicy = "Never"
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Queues["/"]["testing"].Durable).To(BeFalse())
+		Expect(obj).To(HaveCondition("QueueReady").WithStatus("False").WithReason("QueueSettingsMismatch"))
+	})
+
+	It("deletes a queue", func() {
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:     "testing",
+					Vhost:    "/",
+					Messages: 10,
+				},
+			},
+		}
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Queues["/"]).To(BeEmpty())
+	})
+
+	It("retains a queue with the Retain policy", func() {
+		obj.Spec.DeletionPolicy = "Retain"
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+				},
+			},
+		}
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Queues["/"]).To(HaveKey("testing"))
+		Expect(helper.Events).To(Receive(Equal("Normal QueueRetained RabbitMQ queue testing on vhost / retained")))
+	})
+
+	It("blocks deleting a non-empty queue with the DeleteIfEmpty policy", func() {
+		obj.Spec.DeletionPolicy = "DeleteIfEmpty"
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:     "testing",
+					Vhost:    "/",
+					Messages: 10,
+				},
+			},
+		}
+		res, done := helper.MustFinalize()
+		Expect(done).To(BeFalse())
+		Expect(res.RequeueAfter).ToNot(BeZero())
+		Expect(rabbit.Queues["/"]).To(HaveKey("testing"))
+		Expect(obj).To(HaveCondition("QueueReady").WithStatus("False").WithReason("DeletionBlocked"))
+		Expect(helper.Events).To(Receive(Equal("Warning DeletionBlocked RabbitMQ queue testing on vhost / still has 10 messages")))
+	})
+
+	It("deletes an empty queue with the DeleteIfEmpty policy", func() {
+		obj.Spec.DeletionPolicy = "DeleteIfEmpty"
+		rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+			"/": {
+				"testing": {
+					Name:  "testing",
+					Vhost: "/",
+				},
+			},
+		}
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Queues["/"]).To(BeEmpty())
+	})
+
+	Describe("with the Shovel policy", func() {
+		BeforeEach(func() {
+			d := true
+			obj.Spec.Durable = &d
+			obj.Spec.MigrationPolicy = "Shovel"
+		})
+
+		It("starts a migration", func() {
+			rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+				"/": {
+					"testing": {
+						Name:     "testing",
+						Vhost:    "/",
+						Messages: 10,
+					},
+				},
+			}
+			helper.MustReconcile()
+			Expect(obj.Status.Migration).To(PointTo(MatchFields(IgnoreExtras, Fields{
+				"Phase":          Equal("MovingToTemporary"),
+				"TemporaryQueue": Equal("testing.migration"),
+			})))
+			Expect(rabbit.Queues["/"]).To(HaveKey("testing.migration"))
+			Expect(rabbit.Shovels["/"]).To(HaveKeyWithValue("testing-migration", PointTo(MatchFields(IgnoreExtras, Fields{
+				"Definition": MatchFields(IgnoreExtras, Fields{
+					"SourceURI":        Equal("amqp:///%2F"),
+					"SourceQueue":      Equal("testing"),
+					"DestinationQueue": Equal("testing.migration"),
+				}),
+			}))))
+			Expect(helper.Events).To(Receive(Equal("Normal MigrationStarted RabbitMQ queue testing on vhost / migrating through testing.migration")))
+			Expect(obj).To(HaveCondition("QueueReady").WithStatus("False").WithReason("Migrating"))
+		})
+
+		It("copies the bindings to the temporary queue", func() {
+			rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+				"/": {
+					"testing": {
+						Name:     "testing",
+						Vhost:    "/",
+						Messages: 10,
+					},
+				},
+			}
+			rabbit.Bindings["/"] = []*rabbithole.BindingInfo{
+				{Source: "", Destination: "testing", DestinationType: "queue", RoutingKey: "testing", PropertiesKey: "testing"},
+				{Source: "orders", Destination: "testing", DestinationType: "queue", RoutingKey: "new", PropertiesKey: "new"},
+			}
+			helper.MustReconcile()
+			Expect(obj.Status.Migration.Bindings).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"Source":     Equal("orders"),
+				"RoutingKey": Equal("new"),
+			})))
+			bindings, err := rabbit.ListQueueBindings("/", "testing.migration")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bindings).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"Source":     Equal("orders"),
+				"RoutingKey": Equal("new"),
+			})))
+			bindings, err = rabbit.ListQueueBindings("/", "testing")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bindings).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"Source": Equal(""),
+			})))
+		})
+
+		It("moves the bindings back to the recreated queue", func() {
+			obj.Status.Migration = &rabbitv1beta1.RabbitQueueMigration{
+				Phase:          "MovingToTemporary",
+				TemporaryQueue: "testing.migration",
+				Bindings: []rabbitv1beta1.RabbitQueueMigrationBinding{
+					{Source: "orders", RoutingKey: "new", Arguments: &runtime.RawExtension{Raw: []byte(`{"x-match":"all"}`)}},
+				},
+			}
+			rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+				"/": {
+					"testing": {
+						Name:  "testing",
+						Vhost: "/",
+					},
+					"testing.migration": {
+						Name:     "testing.migration",
+						Vhost:    "/",
+						Messages: 10,
+					},
+				},
+			}
+			_, err := rabbit.DeclareBinding("/", rabbithole.BindingInfo{Source: "orders", Destination: "testing.migration", DestinationType: "queue", RoutingKey: "new", Arguments: map[string]interface{}{"x-match": "all"}})
+			Expect(err).ToNot(HaveOccurred())
+			helper.MustReconcile()
+			Expect(obj.Status.Migration.Phase).To(Equal("MovingBack"))
+			bindings, err := rabbit.ListQueueBindings("/", "testing")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bindings).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"Source":     Equal("orders"),
+				"RoutingKey": Equal("new"),
+				"Arguments":  Equal(map[string]interface{}{"x-match": "all"}),
+			})))
+			bindings, err = rabbit.ListQueueBindings("/", "testing.migration")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bindings).To(BeEmpty())
+		})
+
+		It("recreates the queue once it is empty", func() {
+			obj.Status.Migration = &rabbitv1beta1.RabbitQueueMigration{Phase: "MovingToTemporary", TemporaryQueue: "testing.migration"}
+			rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+				"/": {
+					"testing": {
+						Name:  "testing",
+						Vhost: "/",
+					},
+					"testing.migration": {
+						Name:     "testing.migration",
+						Vhost:    "/",
+						Messages: 10,
+					},
+				},
+			}
+			helper.MustReconcile()
+			Expect(obj.Status.Migration.Phase).To(Equal("MovingBack"))
+			Expect(rabbit.Queues["/"]["testing"].Durable).To(BeTrue())
+			Expect(rabbit.Shovels["/"]).To(HaveKeyWithValue("testing-migration", PointTo(MatchFields(IgnoreExtras, Fields{
+				"Definition": MatchFields(IgnoreExtras, Fields{
+					"SourceQueue":      Equal("testing.migration"),
+					"DestinationQueue": Equal("testing"),
+				}),
+			}))))
+			Expect(obj).To(HaveCondition("QueueReady").WithStatus("False").WithReason("Migrating"))
+		})
+
+		It("finishes the migration once the temporary queue is empty", func() {
+			obj.Status.Migration = &rabbitv1beta1.RabbitQueueMigration{Phase: "MovingBack", TemporaryQueue: "testing.migration"}
+			rabbit.Queues = map[string]map[string]*rabbithole.QueueInfo{
+				"/": {
+					"testing": {
+						Name:    "testing",
+						Vhost:   "/",
+						Durable: true,
+					},
+					"testing.migration": {
+						Name:  "testing.migration",
+						Vhost: "/",
+					},
+				},
+			}
+			helper.MustReconcile()
+			Expect(obj.Status.Migration).To(BeNil())
+			Expect(rabbit.Queues["/"]).ToNot(HaveKey("testing.migration"))
+			Expect(helper.Events).To(Receive(Equal("Normal QueueMigrated RabbitMQ queue testing on vhost / migrated")))
+			Expect(obj).To(HaveCondition("QueueReady").WithStatus("True"))
+		})
+	})
 })
@@ -0,0 +1,277 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+)
+
+// Wrapper around the rabbithole client to fill in the management API calls it doesn't support (yet).
+type rabbitholeClient struct {
+	*rabbithole.Client
+	transport http.RoundTripper
+}
+
+// Like rabbithole.ExchangeSettings but with the internal flag.
+type exchangeSettings struct {
+	rabbithole.ExchangeSettings
+	Internal bool `json:"internal,omitempty"`
+}
+
+// Runtime status of a dynamic shovel, which rabbithole doesn't expose.
+type shovelStatus struct {
+	Name   string `json:"name"`
+	Vhost  string `json:"vhost"`
+	Type   string `json:"type"`
+	State  string `json:"state"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Like rabbithole.FederationDefinition but leaves out unset fields so the server defaults apply.
+type federationDefinition struct {
+	Uri            string `json:"uri"`
+	Expires        *int   `json:"expires,omitempty"`
+	MessageTTL     *int   `json:"message-ttl,omitempty"`
+	MaxHops        *int   `json:"max-hops,omitempty"`
+	PrefetchCount  *int   `json:"prefetch-count,omitempty"`
+	ReconnectDelay *int   `json:"reconnect-delay,omitempty"`
+	AckMode        string `json:"ack-mode,omitempty"`
+	TrustUserId    *bool  `json:"trust-user-id,omitempty"`
+}
+
+type federationUpstream struct {
+	Name       string               `json:"name"`
+	Vhost      string               `json:"vhost"`
+	Definition federationDefinition `json:"value"`
+}
+
+// Like rabbithole.VhostInfo but with the metadata fields from newer brokers.
+type vhostInfo struct {
+	Name             string   `json:"name"`
+	Description      string   `json:"description,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+	DefaultQueueType string   `json:"default_queue_type,omitempty"`
+	Tracing          bool     `json:"tracing"`
+	Messages         int      `json:"messages"`
+}
+
+// Like rabbithole.VhostSettings but with the metadata fields from newer brokers. Tags are sent comma-separated for older brokers.
+type vhostSettings struct {
+	Description      string `json:"description"`
+	Tags             string `json:"tags"`
+	DefaultQueueType string `json:"default_queue_type,omitempty"`
+	Tracing          bool   `json:"tracing"`
+}
+
+// Replica details of a quorum queue, which rabbithole doesn't decode.
+type queueMembers struct {
+	Leader  string   `json:"leader"`
+	Members []string `json:"members"`
+	Online  []string `json:"online"`
+}
+
+// Limits set on a vhost, keyed by limit name.
+type vhostLimits struct {
+	Vhost string         `json:"vhost"`
+	Value map[string]int `json:"value"`
+}
+
+// Limits set on a user, keyed by limit name.
+type userLimits struct {
+	User  string         `json:"user"`
+	Value map[string]int `json:"value"`
+}
+
+func (c *rabbitholeClient) DeclareExchange(vhost, exchange string, settings exchangeSettings) (*http.Response, error) {
+	if settings.Arguments == nil {
+		settings.Arguments = map[string]interface{}{}
+	}
+	return c.do("PUT", "exchanges/"+url.PathEscape(vhost)+"/"+url.PathEscape(exchange), settings)
+}
+
+func (c *rabbitholeClient) GetVhost(vhost string) (*vhostInfo, error) {
+	info := &vhostInfo{}
+	err := c.getJSON("vhosts/"+url.PathEscape(vhost), info)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (c *rabbitholeClient) PutVhost(vhost string, settings vhostSettings) (*http.Response, error) {
+	return c.do("PUT", "vhosts/"+url.PathEscape(vhost), settings)
+}
+
+func (c *rabbitholeClient) GetFederationUpstream(vhost, name string) (*federationUpstream, error) {
+	upstream := &federationUpstream{}
+	err := c.getJSON("parameters/federation-upstream/"+url.PathEscape(vhost)+"/"+url.PathEscape(name), upstream)
+	if err != nil {
+		return nil, err
+	}
+	return upstream, nil
+}
+
+func (c *rabbitholeClient) PutFederationUpstream(vhost, name string, definition federationDefinition) (*http.Response, error) {
+	body := map[string]interface{}{"vhost": vhost, "name": name, "component": "federation-upstream", "value": definition}
+	return c.do("PUT", "parameters/federation-upstream/"+url.PathEscape(vhost)+"/"+url.PathEscape(name), body)
+}
+
+func (c *rabbitholeClient) ListOperatorPoliciesIn(vhost string) ([]rabbithole.Policy, error) {
+	policies := []rabbithole.Policy{}
+	err := c.getJSON("operator-policies/"+url.PathEscape(vhost), &policies)
+	return policies, err
+}
+
+func (c *rabbitholeClient) PutOperatorPolicy(vhost, name string, policy rabbithole.Policy) (*http.Response, error) {
+	return c.do("PUT", "operator-policies/"+url.PathEscape(vhost)+"/"+url.PathEscape(name), policy)
+}
+
+func (c *rabbitholeClient) DeleteOperatorPolicy(vhost, name string) (*http.Response, error) {
+	return c.do("DELETE", "operator-policies/"+url.PathEscape(vhost)+"/"+url.PathEscape(name), nil)
+}
+
+func (c *rabbitholeClient) GetVhostLimits(vhost string) (map[string]int, error) {
+	limits := []vhostLimits{}
+	err := c.getJSON("vhost-limits/"+url.PathEscape(vhost), &limits)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]int{}
+	for _, l := range limits {
+		for name, value := range l.Value {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
+
+func (c *rabbitholeClient) PutVhostLimit(vhost, name string, value int) (*http.Response, error) {
+	return c.do("PUT", "vhost-limits/"+url.PathEscape(vhost)+"/"+url.PathEscape(name), map[string]int{"value": value})
+}
+
+func (c *rabbitholeClient) DeleteVhostLimit(vhost, name string) (*http.Response, error) {
+	return c.do("DELETE", "vhost-limits/"+url.PathEscape(vhost)+"/"+url.PathEscape(name), nil)
+}
+
+func (c *rabbitholeClient) GetUserLimits(username string) (map[string]int, error) {
+	limits := []userLimits{}
+	err := c.getJSON("user-limits/"+url.PathEscape(username), &limits)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]int{}
+	for _, l := range limits {
+		for name, value := range l.Value {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
+
+func (c *rabbitholeClient) PutUserLimit(username, name string, value int) (*http.Response, error) {
+	return c.do("PUT", "user-limits/"+url.PathEscape(username)+"/"+url.PathEscape(name), map[string]int{"value": value})
+}
+
+func (c *rabbitholeClient) DeleteUserLimit(username, name string) (*http.Response, error) {
+	return c.do("DELETE", "user-limits/"+url.PathEscape(username)+"/"+url.PathEscape(name), nil)
+}
+
+func (c *rabbitholeClient) GetQueueMembers(vhost, queue string) (*queueMembers, error) {
+	members := &queueMembers{}
+	err := c.getJSON("queues/"+url.PathEscape(vhost)+"/"+url.PathEscape(queue), members)
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (c *rabbitholeClient) AddQueueMember(vhost, queue, node string) (*http.Response, error) {
+	return c.do("POST", "queues/quorum/"+url.PathEscape(vhost)+"/"+url.PathEscape(queue)+"/replicas/add", map[string]string{"node": node})
+}
+
+func (c *rabbitholeClient) DeleteQueueMember(vhost, queue, node string) (*http.Response, error) {
+	return c.do("DELETE", "queues/quorum/"+url.PathEscape(vhost)+"/"+url.PathEscape(queue)+"/replicas/delete", map[string]string{"node": node})
+}
+
+func (c *rabbitholeClient) ListShovelStatusIn(vhost string) ([]shovelStatus, error) {
+	statuses := []shovelStatus{}
+	err := c.getJSON("shovels/"+url.PathEscape(vhost), &statuses)
+	return statuses, err
+}
+
+// GET a path from the management API and decode the response into out.
+func (c *rabbitholeClient) getJSON(path string, out interface{}) error {
+	resp, err := c.do("GET", path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Send a request to the management API, mirroring the error handling in rabbithole.
+func (c *rabbitholeClient) do(method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequest(method, c.Endpoint+"/api/"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Close = true
+	req.SetBasicAuth(c.Username, c.Password)
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	httpc := &http.Client{Transport: c.transport}
+	resp, err := httpc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return nil, rabbithole.ErrorResponse{StatusCode: resp.StatusCode, Message: "API responded with a 401 Unauthorized"}
+	}
+	// Same as rabbithole, a 404 on a delete is fine.
+	if method == "DELETE" && resp.StatusCode == http.StatusNotFound {
+		return resp, nil
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		rme := rabbithole.ErrorResponse{}
+		err = json.NewDecoder(resp.Body).Decode(&rme)
+		if err != nil {
+			rme.Message = fmt.Sprintf("Error %d from RabbitMQ: %s", resp.StatusCode, err)
+		}
+		rme.StatusCode = resp.StatusCode
+		return nil, rme
+	}
+	return resp, nil
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"bytes"
+	"context"
+	texttemplate "text/template"
+
+	"github.com/Masterminds/sprig"
+	cu "github.com/coderanger/controller-utils"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+type secretTemplateComponent struct{}
+
+type secretTemplateComponentWatchMap struct {
+	client client.Client
+	log    logr.Logger
+}
+
+// Same shape as the data the template component passes in, so user templates look like the built-in ones.
+type secretTemplateData struct {
+	Object cu.Object
+	Data   map[string]interface{}
+}
+
+func SecretTemplate() *secretTemplateComponent {
+	return &secretTemplateComponent{}
+}
+
+func (comp *secretTemplateComponent) Setup(ctx *cu.Context, bldr *ctrl.Builder) error {
+	bldr.Watches(
+		&source.Kind{Type: &corev1.ConfigMap{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: &secretTemplateComponentWatchMap{client: ctx.Client, log: ctx.Log}},
+	)
+	// The other owned Secrets are only watched for their own keys, so put back any changes to the rendered one.
+	bldr.Watches(
+		&source.Kind{Type: &corev1.Secret{}},
+		&handler.EnqueueRequestForOwner{OwnerType: &rabbitv1beta1.RabbitUser{}, IsController: true},
+	)
+	return nil
+}
+
+// Watch map function used above.
+// Obj is a ConfigMap that just got an event, map it back to any User in the same namespace using it as a template.
+func (wm *secretTemplateComponentWatchMap) Map(obj handler.MapObject) []reconcile.Request {
+	requests := []reconcile.Request{}
+	users := &rabbitv1beta1.RabbitUserList{}
+	err := wm.client.List(context.Background(), users, client.InNamespace(obj.Meta.GetNamespace()))
+	if err != nil {
+		wm.log.Error(err, "error listing users")
+		// TODO Metric to track this for alerting.
+		return requests
+	}
+	for _, user := range users.Items {
+		if user.Spec.SecretTemplateRef != nil && user.Spec.SecretTemplateRef.Name == obj.Meta.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      user.Name,
+					Namespace: user.Namespace,
+				},
+			})
+		}
+	}
+	return requests
+}
+
+func (comp *secretTemplateComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitUser)
+	ref := obj.Spec.SecretTemplateRef
+	if ref == nil {
+		return cu.Result{}, deleteTemplateSecret(ctx, obj)
+	}
+
+	// The user component stashes this once the user is in place, nothing useful to render without it.
+	_, ok := ctx.Data["uri"]
+	if !ok {
+		return cu.Result{}, nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := ctx.Client.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: obj.Namespace}, configMap)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error getting secret template configmap %s/%s", obj.Namespace, ref.Name)
+	}
+	key := ref.Key
+	if key == "" {
+		key = "secret.yml"
+	}
+	template, ok := configMap.Data[key]
+	if !ok {
+		return cu.Result{}, errors.Errorf("key %s not found in configmap %s/%s", key, obj.Namespace, ref.Name)
+	}
+
+	// The template is user supplied, so it only gets sprig's hermetic functions (no env or expandenv to read the
+	// operator's own credentials with) and is applied here rather than handed to the template component, which would
+	// render it again with the full function map.
+	tmpl, err := texttemplate.New(key).Funcs(sprig.HermeticTxtFuncMap()).Parse(template)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error parsing secret template from configmap %s/%s", obj.Namespace, ref.Name)
+	}
+	var out bytes.Buffer
+	err = tmpl.Execute(&out, secretTemplateData{Object: obj, Data: ctx.Data})
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error rendering secret template from configmap %s/%s", obj.Namespace, ref.Name)
+	}
+	rendered := &unstructured.Unstructured{}
+	err = yaml.NewYAMLOrJSONDecoder(&out, out.Len()+1).Decode(&rendered.Object)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error parsing rendered secret template from configmap %s/%s", obj.Namespace, ref.Name)
+	}
+
+	// Only let it write a Secret next to the user.
+	gvk := rendered.GroupVersionKind()
+	if gvk.Group != "" || gvk.Kind != "Secret" {
+		return cu.Result{}, errors.Errorf("secret template must render a Secret, not %s", gvk.Kind)
+	}
+	if rendered.GetNamespace() != "" && rendered.GetNamespace() != obj.Namespace {
+		return cu.Result{}, errors.Errorf("secret template must render into namespace %s, not %s", obj.Namespace, rendered.GetNamespace())
+	}
+	if rendered.GetName() == obj.Name+"-rabbituser" {
+		return cu.Result{}, errors.Errorf("secret template must not replace the credentials secret %s", rendered.GetName())
+	}
+	rendered.SetNamespace(obj.Namespace)
+
+	// Don't take over a Secret something else created.
+	existing := &corev1.Secret{}
+	err = ctx.Client.Get(ctx, types.NamespacedName{Name: rendered.GetName(), Namespace: obj.Namespace}, existing)
+	if err == nil {
+		owner := metav1.GetControllerOf(existing)
+		if owner == nil || owner.UID != obj.UID {
+			return cu.Result{}, errors.Errorf("secret %s already exists and is not owned by this user", rendered.GetName())
+		}
+	} else if !kerrors.IsNotFound(err) {
+		return cu.Result{}, errors.Wrapf(err, "error getting secret %s/%s", obj.Namespace, rendered.GetName())
+	}
+
+	err = controllerutil.SetControllerReference(obj, rendered, ctx.Scheme)
+	if err != nil {
+		return cu.Result{}, errors.Wrap(err, "error setting owner reference")
+	}
+	force := true
+	err = ctx.Client.Patch(ctx, rendered, client.Apply, &client.PatchOptions{Force: &force, FieldManager: ctx.FieldManager})
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error applying secret %s/%s", obj.Namespace, rendered.GetName())
+	}
+
+	// Clean up after a template that used to render a different name.
+	if obj.Status.TemplateSecretName != rendered.GetName() {
+		err = deleteTemplateSecret(ctx, obj)
+		if err != nil {
+			return cu.Result{}, err
+		}
+		obj.Status.TemplateSecretName = rendered.GetName()
+	}
+	return cu.Result{}, nil
+}
+
+// Delete the Secret previously rendered from the template, as long as it's still owned by this user.
+func deleteTemplateSecret(ctx *cu.Context, obj *rabbitv1beta1.RabbitUser) error {
+	name := obj.Status.TemplateSecretName
+	if name == "" {
+		return nil
+	}
+	existing := &corev1.Secret{}
+	err := ctx.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: obj.Namespace}, existing)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error getting secret %s/%s", obj.Namespace, name)
+	}
+	if err == nil {
+		owner := metav1.GetControllerOf(existing)
+		if owner != nil && owner.UID == obj.UID {
+			err = ctx.Client.Delete(ctx, existing)
+			if err != nil && !kerrors.IsNotFound(err) {
+				return errors.Wrapf(err, "error deleting secret %s/%s", obj.Namespace, name)
+			}
+		}
+	}
+	obj.Status.TemplateSecretName = ""
+	return nil
+}
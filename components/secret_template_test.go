@@ -0,0 +1,169 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"context"
+	"net/url"
+
+	cu "github.com/coderanger/controller-utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("SecretTemplate component", func() {
+	var obj *rabbitv1beta1.RabbitUser
+	var helper *cu.UnitHelper
+
+	BeforeEach(func() {
+		comp := SecretTemplate()
+		obj = &rabbitv1beta1.RabbitUser{
+			ObjectMeta: metav1.ObjectMeta{UID: "testing-uid"},
+			Spec: rabbitv1beta1.RabbitUserSpec{
+				SecretTemplateRef: &rabbitv1beta1.ConfigMapRef{Name: "templates"},
+			},
+		}
+		helper = suiteHelper.Setup(comp, obj)
+		helper.Ctx.Data["uri"] = &url.URL{Scheme: "amqp", Host: "testhost", User: url.UserPassword("testing", "password")}
+		helper.Ctx.Data["username"] = "testing"
+		helper.Ctx.Data["RABBIT_PASSWORD"] = "password"
+	})
+
+	createTemplate := func(template string) {
+		helper.TestClient.Create(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "templates", Namespace: "default"},
+			Data:       map[string]string{"secret.yml": template},
+		})
+	}
+
+	It("does nothing without a template", func() {
+		obj.Spec.SecretTemplateRef = nil
+		helper.MustReconcile()
+	})
+
+	It("does nothing before the user exists", func() {
+		delete(helper.Ctx.Data, "uri")
+		helper.MustReconcile()
+	})
+
+	It("errors if the configmap is missing", func() {
+		_, err := helper.Reconcile()
+		Expect(err).To(MatchError(ContainSubstring("error getting secret template configmap default/templates")))
+	})
+
+	It("errors if the key is missing", func() {
+		obj.Spec.SecretTemplateRef.Key = "other.yml"
+		createTemplate("")
+		_, err := helper.Reconcile()
+		Expect(err).To(MatchError("key other.yml not found in configmap default/templates"))
+	})
+
+	It("rejects env lookups", func() {
+		createTemplate(`
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .Object.Name }}-custom
+stringData:
+  LEAKED: {{ env "DEFAULT_CONNECTION_PASSWORD" | quote }}
+`)
+		_, err := helper.Reconcile()
+		Expect(err).To(MatchError(ContainSubstring(`function "env" not defined`)))
+	})
+
+	It("rejects a secret it does not own", func() {
+		helper.TestClient.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing-custom", Namespace: "default"},
+		})
+		createTemplate(`
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .Object.Name }}-custom
+`)
+		_, err := helper.Reconcile()
+		Expect(err).To(MatchError("secret testing-custom already exists and is not owned by this user"))
+	})
+
+	It("rejects other kinds of object", func() {
+		createTemplate(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: other
+`)
+		_, err := helper.Reconcile()
+		Expect(err).To(MatchError("secret template must render a Secret, not ConfigMap"))
+	})
+
+	It("rejects a secret in another namespace", func() {
+		createTemplate(`
+apiVersion: v1
+kind: Secret
+metadata:
+  name: other
+  namespace: kube-system
+`)
+		_, err := helper.Reconcile()
+		Expect(err).To(MatchError("secret template must render into namespace default, not kube-system"))
+	})
+
+	It("rejects the credentials secret", func() {
+		createTemplate(`
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .Object.Name }}-rabbituser
+`)
+		_, err := helper.Reconcile()
+		Expect(err).To(MatchError("secret template must not replace the credentials secret testing-rabbituser"))
+	})
+
+	It("deletes the rendered secret when the template is removed", func() {
+		controller := true
+		helper.TestClient.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "testing-custom",
+				Namespace:       "default",
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "rabbitmq.coderanger.net/v1beta1", Kind: "RabbitUser", Name: "testing", UID: obj.UID, Controller: &controller}},
+			},
+		})
+		obj.Spec.SecretTemplateRef = nil
+		obj.Status.TemplateSecretName = "testing-custom"
+		helper.MustReconcile()
+		Expect(obj.Status.TemplateSecretName).To(BeEmpty())
+		err := helper.Client.Get(context.Background(), types.NamespacedName{Name: "testing-custom", Namespace: "default"}, &corev1.Secret{})
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("leaves an old secret it does not own alone", func() {
+		helper.TestClient.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing-custom", Namespace: "default"},
+		})
+		obj.Spec.SecretTemplateRef = nil
+		obj.Status.TemplateSecretName = "testing-custom"
+		helper.MustReconcile()
+		Expect(obj.Status.TemplateSecretName).To(BeEmpty())
+		helper.TestClient.GetName("testing-custom", &corev1.Secret{})
+	})
+})
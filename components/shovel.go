@@ -0,0 +1,244 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package components
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"time"
+
+	cu "github.com/coderanger/controller-utils"
+	"github.com/go-logr/logr"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+type shovelComponent struct {
+	clientFactory rabbitClientFactory
+}
+
+type shovelComponentWatchMap struct {
+	client client.Client
+	log    logr.Logger
+}
+
+func Shovel() *shovelComponent {
+	return &shovelComponent{clientFactory: rabbitholeClientFactory}
+}
+
+func (_ *shovelComponent) GetReadyCondition() string {
+	return "ShovelReady"
+}
+
+func (comp *shovelComponent) Setup(ctx *cu.Context, bldr *ctrl.Builder) error {
+	bldr.Watches(
+		&source.Kind{Type: &corev1.Secret{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: &shovelComponentWatchMap{client: ctx.Client, log: ctx.Log}},
+	)
+	return watchClusters(ctx, bldr)
+}
+
+// Watch map function used above.
+// Obj is a Secret that just got an event, map it back to any shovel in the same namespace with an endpoint using it.
+func (wm *shovelComponentWatchMap) Map(obj handler.MapObject) []reconcile.Request {
+	requests := []reconcile.Request{}
+	shovels := &rabbitv1beta1.RabbitShovelList{}
+	err := wm.client.List(context.Background(), shovels, client.InNamespace(obj.Meta.GetNamespace()))
+	if err != nil {
+		wm.log.Error(err, "error listing shovels")
+		// TODO Metric to track this for alerting.
+		return requests
+	}
+	for _, shovel := range shovels.Items {
+		if shovelEndpointUsesSecret(&shovel.Spec.Source, obj.Meta.GetName()) || shovelEndpointUsesSecret(&shovel.Spec.Destination, obj.Meta.GetName()) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      shovel.Name,
+					Namespace: shovel.Namespace,
+				},
+			})
+		}
+	}
+	return requests
+}
+
+func (comp *shovelComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitShovel)
+	ctx.Conditions.SetUnknown("ShovelReady", "Unknown")
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	// Get the core data for the shovel from the object.
+	shovel := obj.Spec.ShovelName
+	vhost := obj.Spec.Vhost
+
+	// Work out the AMQP URIs for both ends.
+	srcURI, err := shovelEndpointURI(ctx, &obj.Spec.Source, obj.Namespace, ctx.Client)
+	if err != nil {
+		return cu.Result{}, errors.Wrap(err, "error building source URI")
+	}
+	destURI, err := shovelEndpointURI(ctx, &obj.Spec.Destination, obj.Namespace, ctx.Client)
+	if err != nil {
+		return cu.Result{}, errors.Wrap(err, "error building destination URI")
+	}
+
+	definition := rabbithole.ShovelDefinition{
+		SourceURI:                    srcURI,
+		SourceQueue:                  obj.Spec.Source.Queue,
+		SourceExchange:               obj.Spec.Source.Exchange,
+		SourceExchangeKey:            obj.Spec.Source.ExchangeKey,
+		SourcePrefetchCount:          obj.Spec.PrefetchCount,
+		DestinationURI:               destURI,
+		DestinationQueue:             obj.Spec.Destination.Queue,
+		DestinationExchange:          obj.Spec.Destination.Exchange,
+		DestinationExchangeKey:       obj.Spec.Destination.ExchangeKey,
+		DestinationAddForwardHeaders: obj.Spec.AddForwardHeaders,
+		AckMode:                      obj.Spec.AckMode,
+		ReconnectDelay:               obj.Spec.ReconnectDelay,
+		DeleteAfter:                  rabbithole.DeleteAfter(obj.Spec.DeleteAfter),
+	}
+
+	// Check if the shovel already exists and matches.
+	var createShovel, updateShovel bool
+	existingShovel, err := rmqc.GetShovel(vhost, shovel)
+	if err != nil {
+		rabbitErr, ok := err.(rabbithole.ErrorResponse)
+		if ok && rabbitErr.StatusCode == 404 {
+			createShovel = true
+		} else {
+			return cu.Result{}, errors.Wrapf(err, "error getting shovel %s on vhost %s", shovel, vhost)
+		}
+	} else {
+		updateShovel = !reflect.DeepEqual(existingShovel.Definition, definition)
+	}
+
+	// Create or update the shovel parameter if needed.
+	if createShovel || updateShovel {
+		_, err := rmqc.DeclareShovel(vhost, shovel, definition)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error putting shovel %s on vhost %s", shovel, vhost)
+		}
+
+		// Create an event.
+		var event, eventMessage string
+		if createShovel {
+			event = "ShovelCreated"
+			eventMessage = "created"
+		} else {
+			event = "ShovelUpdated"
+			eventMessage = "updated"
+		}
+		ctx.Events.Eventf(obj, "Normal", event, "RabbitMQ shovel %s on vhost %s %s", shovel, vhost, eventMessage)
+	}
+
+	// Check the runtime state of the shovel.
+	statuses, err := rmqc.ListShovelStatusIn(vhost)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error getting status of shovel %s on vhost %s", shovel, vhost)
+	}
+	var status *shovelStatus
+	for _, s := range statuses {
+		if s.Name == shovel {
+			status = &s
+			break
+		}
+	}
+	if status == nil || status.State == "starting" {
+		// Either still starting or so new it hasn't shown up yet, check back soon.
+		ctx.Conditions.SetfFalse("ShovelReady", "ShovelStarting", "RabbitMQ shovel %s on vhost %s is starting", shovel, vhost)
+		return cu.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+	if status.State != "running" {
+		ctx.Conditions.SetfFalse("ShovelReady", "ShovelTerminated", "RabbitMQ shovel %s on vhost %s is %s: %s", shovel, vhost, status.State, status.Reason)
+		return cu.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	// Keep checking in case the shovel stops later on.
+	ctx.Conditions.SetfTrue("ShovelReady", "ShovelRunning", "RabbitMQ shovel %s on vhost %s is running", shovel, vhost)
+	return cu.Result{RequeueAfter: time.Minute}, nil
+}
+
+func (comp *shovelComponent) Finalize(ctx *cu.Context) (cu.Result, bool, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitShovel)
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, false, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	_, err = rmqc.DeleteShovel(obj.Spec.Vhost, obj.Spec.ShovelName)
+	if err != nil {
+		return cu.Result{}, false, errors.Wrapf(err, "error deleting rabbitmq shovel %s on vhost %s", obj.Spec.ShovelName, obj.Spec.Vhost)
+	}
+	return cu.Result{}, true, nil
+}
+
+// Build the AMQP URI for one end of a shovel. Never uses the shovel's own connection, since that would put the admin
+// credentials into the shovel parameter.
+func shovelEndpointURI(ctx context.Context, endpoint *rabbitv1beta1.RabbitShovelEndpoint, namespace string, c client.Client) (string, error) {
+	// Also checked by the webhook, but that might not be running.
+	err := endpoint.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	var uri *url.URL
+	if endpoint.UserSecretRef != nil {
+		rawURI, err := getSecretValue(ctx, c, endpoint.UserSecretRef, namespace, "RABBIT_URL")
+		if err != nil {
+			return "", err
+		}
+		uri, err = url.Parse(rawURI)
+		if err != nil {
+			return "", errors.Wrapf(err, "error parsing URI from user secret %s/%s", namespace, endpoint.UserSecretRef.Name)
+		}
+	} else {
+		connectionURI, _, err := resolveConnection(ctx, endpoint.Connection, namespace, c)
+		if err != nil {
+			return "", err
+		}
+		uri = amqpURI(connectionURI)
+	}
+
+	if endpoint.Vhost != "" {
+		uri.Path = "/" + endpoint.Vhost
+		uri.RawPath = "/" + url.PathEscape(endpoint.Vhost)
+	}
+	return uri.String(), nil
+}
+
+// Check if either kind of credentials for one end of a shovel come from the given Secret.
+func shovelEndpointUsesSecret(endpoint *rabbitv1beta1.RabbitShovelEndpoint, name string) bool {
+	if endpoint.UserSecretRef != nil && endpoint.UserSecretRef.Name == name {
+		return true
+	}
+	return endpoint.Connection != nil && endpoint.Connection.PasswordSecretRef != nil && endpoint.Connection.PasswordSecretRef.Name == name
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package components
+
+import (
+	cu "github.com/coderanger/controller-utils"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+type topicPermissionsComponent struct {
+	clientFactory rabbitClientFactory
+}
+
+// Topic permissions are unique per vhost and exchange.
+type topicPermissionKey struct {
+	vhost    string
+	exchange string
+}
+
+func TopicPermissions() *topicPermissionsComponent {
+	return &topicPermissionsComponent{clientFactory: rabbitholeClientFactory}
+}
+
+func (comp *topicPermissionsComponent) Setup(ctx *cu.Context, bldr *ctrl.Builder) error {
+	// Same watch as the permissions component, duplicate requests are collapsed by the work queue.
+	bldr.Watches(
+		&source.Kind{Type: &rabbitv1beta1.RabbitVhost{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: &permissionsComponentWatchMap{client: ctx.Client, log: ctx.Log}},
+	)
+	return nil
+}
+
+func (comp *topicPermissionsComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitUser)
+	ctx.Conditions.SetUnknown("TopicPermissionsReady", "Unknown")
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	// Get the core data for the user from the object/context.
+	username := obj.ActiveUsername()
+	if username == "" { // TODO Switch this to a defaulting webhook.
+		username = obj.Name
+	}
+
+	// Look for `*` vhosts in the spec, move the rest into a holding pen.
+	specPermMap := map[topicPermissionKey]*rabbitv1beta1.RabbitTopicPermission{}
+	allVhostPerms := []*rabbitv1beta1.RabbitTopicPermission{}
+	for _, perm := range obj.Spec.TopicPermissions {
+		permCopy := perm
+		if perm.Vhost == "*" {
+			allVhostPerms = append(allVhostPerms, &permCopy)
+		} else {
+			specPermMap[topicPermissionKey{vhost: perm.Vhost, exchange: perm.Exchange}] = &permCopy
+		}
+	}
+	if len(allVhostPerms) != 0 {
+		// Expand the * pseudo-vhost.
+		vhosts, err := rmqc.ListVhosts()
+		if err != nil {
+			return cu.Result{}, errors.Wrap(err, "error listing vhosts for * vhost topic permissions")
+		}
+		for _, vhost := range vhosts {
+			for _, perm := range allVhostPerms {
+				key := topicPermissionKey{vhost: vhost.Name, exchange: perm.Exchange}
+				_, alreadySet := specPermMap[key]
+				if !alreadySet {
+					specPermMap[key] = perm
+				}
+			}
+		}
+	}
+
+	// Get all topic permissions for the user. Add all mentioned in spec and remove unwanted.
+	permissions, err := rmqc.ListTopicPermissionsOf(username)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error listing topic permissions for user %s", username)
+	}
+	existingPermMap := map[topicPermissionKey]rabbithole.TopicPermissionInfo{}
+	for _, perm := range permissions {
+		existingPermMap[topicPermissionKey{vhost: perm.Vhost, exchange: perm.Exchange}] = perm
+	}
+
+	for key, perm := range specPermMap {
+		var createPermissions, updatePermissions bool
+
+		existingPerm, ok := existingPermMap[key]
+		if !ok {
+			createPermissions = true
+		} else {
+			// Delete the entry in permMap so we can use it as a double-ended diff too.
+			delete(existingPermMap, key)
+			updatePermissions = existingPerm.Read != perm.Read || existingPerm.Write != perm.Write
+		}
+
+		if createPermissions || updatePermissions {
+			_, err := rmqc.UpdateTopicPermissionsIn(key.vhost, username, rabbithole.TopicPermissions{
+				Exchange: key.exchange,
+				Read:     perm.Read,
+				Write:    perm.Write,
+			})
+			if err != nil {
+				return cu.Result{}, errors.Wrapf(err, "error updating topic permissions for user %s and exchange %s in vhost %s", username, key.exchange, key.vhost)
+			}
+
+			// Create an event.
+			var event, eventMessage string
+			if createPermissions {
+				event = "TopicPermissionsCreated"
+				eventMessage = "created"
+			} else {
+				event = "TopicPermissionsUpdated"
+				eventMessage = "updated"
+			}
+			ctx.Events.Eventf(obj, "Normal", event, "RabbitMQ topic permissions for user %s on exchange %s in vhost %s %s", username, key.exchange, key.vhost, eventMessage)
+		}
+	}
+
+	// Remove any topic permissions that exist in RabbitMQ but not in the Spec.
+	for key := range existingPermMap {
+		_, err := rmqc.DeleteTopicPermissionsIn(key.vhost, username, key.exchange)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error removing topic permissions for user %s and exchange %s in vhost %s", username, key.exchange, key.vhost)
+		}
+		ctx.Events.Eventf(obj, "Normal", "TopicPermissionsDeleted", "RabbitMQ topic permissions for user %s on exchange %s in vhost %s deleted", username, key.exchange, key.vhost)
+	}
+
+	ctx.Conditions.SetTrue("TopicPermissionsReady", "TopicPermissionsSynced")
+	return cu.Result{}, nil
+}
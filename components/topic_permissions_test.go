@@ -0,0 +1,183 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package components
+
+import (
+	cu "github.com/coderanger/controller-utils"
+	. "github.com/coderanger/controller-utils/tests/matchers"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("TopicPermissions component", func() {
+	var obj *rabbitv1beta1.RabbitUser
+	var rabbit *fakeRabbitClient
+	var helper *cu.UnitHelper
+
+	BeforeEach(func() {
+		rabbit = newFakeRabbitClient()
+		comp := TopicPermissions()
+		comp.clientFactory = rabbit.Factory
+		obj = &rabbitv1beta1.RabbitUser{
+			Spec: rabbitv1beta1.RabbitUserSpec{
+				Connection: rabbitv1beta1.RabbitConnection{
+					Host:     "testhost",
+					Username: "testuser",
+				},
+				TopicPermissions: []rabbitv1beta1.RabbitTopicPermission{
+					{
+						Vhost:    "/",
+						Exchange: "events",
+						Write:    "^orders\\.",
+						Read:     ".*",
+					},
+				},
+			},
+		}
+		helper = suiteHelper.Setup(comp, obj)
+	})
+
+	It("creates a topic permission", func() {
+		helper.MustReconcile()
+		Expect(rabbit.TopicPermissions).To(MatchAllKeys(Keys{
+			"testing": MatchAllKeys(Keys{
+				"/": MatchAllKeys(Keys{
+					"events": PointTo(MatchFields(IgnoreExtras, Fields{
+						"Read":  Equal(".*"),
+						"Write": Equal("^orders\\."),
+					})),
+				}),
+			}),
+		}))
+		Expect(helper.Events).To(Receive(Equal("Normal TopicPermissionsCreated RabbitMQ topic permissions for user testing on exchange events in vhost / created")))
+		Expect(obj).To(HaveCondition("TopicPermissionsReady").WithStatus("True"))
+	})
+
+	It("updates a topic permission when Write does not match", func() {
+		rabbit.TopicPermissions = map[string]map[string]map[string]*rabbithole.TopicPermissionInfo{
+			"testing": {
+				"/": {
+					"events": {
+						User:     "testing",
+						Vhost:    "/",
+						Exchange: "events",
+						Read:     ".*",
+						Write:    ".*",
+					},
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.TopicPermissions["testing"]["/"]["events"].Write).To(Equal("^orders\\."))
+		Expect(helper.Events).To(Receive(Equal("Normal TopicPermissionsUpdated RabbitMQ topic permissions for user testing on exchange events in vhost / updated")))
+	})
+
+	It("does not update any topic permissions when all values match", func() {
+		rabbit.TopicPermissions = map[string]map[string]map[string]*rabbithole.TopicPermissionInfo{
+			"testing": {
+				"/": {
+					"events": {
+						User:     "testing",
+						Vhost:    "/",
+						Exchange: "events",
+						Read:     ".*",
+						Write:    "^orders\\.",
+					},
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(helper.Events).ToNot(Receive())
+	})
+
+	It("deletes a topic permission not in the spec", func() {
+		rabbit.TopicPermissions = map[string]map[string]map[string]*rabbithole.TopicPermissionInfo{
+			"testing": {
+				"/": {
+					"events": {
+						User:     "testing",
+						Vhost:    "/",
+						Exchange: "events",
+						Read:     ".*",
+						Write:    "^orders\\.",
+					},
+					"other": {
+						User:     "testing",
+						Vhost:    "/",
+						Exchange: "other",
+						Read:     ".*",
+						Write:    ".*",
+					},
+				},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.TopicPermissions).To(MatchAllKeys(Keys{
+			"testing": MatchAllKeys(Keys{
+				"/": MatchAllKeys(Keys{
+					"events": Not(BeNil()),
+				}),
+			}),
+		}))
+		Expect(helper.Events).To(Receive(Equal("Normal TopicPermissionsDeleted RabbitMQ topic permissions for user testing on exchange other in vhost / deleted")))
+	})
+
+	It("decodes a * vhost as 'all vhosts'", func() {
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name: "testing1",
+			},
+			{
+				Name: "testing2",
+			},
+		}
+		obj.Spec.TopicPermissions = []rabbitv1beta1.RabbitTopicPermission{
+			{
+				Vhost:    "testing1",
+				Exchange: "events",
+				Write:    "foo",
+				Read:     "foo",
+			},
+			{
+				Vhost:    "*",
+				Exchange: "events",
+				Write:    "bar",
+				Read:     "bar",
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.TopicPermissions).To(MatchAllKeys(Keys{
+			"testing": MatchAllKeys(Keys{
+				"testing1": MatchAllKeys(Keys{
+					"events": PointTo(MatchFields(IgnoreExtras, Fields{
+						"Read":  Equal("foo"),
+						"Write": Equal("foo"),
+					})),
+				}),
+				"testing2": MatchAllKeys(Keys{
+					"events": PointTo(MatchFields(IgnoreExtras, Fields{
+						"Read":  Equal("bar"),
+						"Write": Equal("bar"),
+					})),
+				}),
+			}),
+		}))
+	})
+})
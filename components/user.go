@@ -18,36 +18,89 @@ limitations under the License.
 package components
 
 import (
+	"context"
+	"crypto/md5"
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/base64"
+	"fmt"
 	"hash"
 	"io/ioutil"
 	"net/url"
-	"strings"
 	"time"
 
 	cu "github.com/coderanger/controller-utils"
+	"github.com/go-logr/logr"
 	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
 )
 
-const DEFAULT_HASH_ALGORITHM = rabbithole.HashingAlgorithmSHA256
+// Map the algorithm names used in the spec to the ones used by RabbitMQ.
+var hashingAlgorithmNames = map[string]rabbithole.HashingAlgorithm{
+	"sha256": rabbithole.HashingAlgorithmSHA256,
+	"sha512": rabbithole.HashingAlgorithmSHA512,
+	"md5":    rabbithole.HashingAlgorithmMD5,
+}
 
 type userComponent struct {
 	clientFactory rabbitClientFactory
 }
 
+type userComponentWatchMap struct {
+	client client.Client
+	log    logr.Logger
+}
+
 func User() *userComponent {
 	return &userComponent{clientFactory: rabbitholeClientFactory}
 }
 
+func (comp *userComponent) Setup(ctx *cu.Context, bldr *ctrl.Builder) error {
+	bldr.Watches(
+		&source.Kind{Type: &corev1.Secret{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: &userComponentWatchMap{client: ctx.Client, log: ctx.Log}},
+	)
+	return watchClusters(ctx, bldr)
+}
+
+// Watch map function used above.
+// Obj is a Secret that just got an event, map it back to any User in the same namespace taking its password from it.
+func (wm *userComponentWatchMap) Map(obj handler.MapObject) []reconcile.Request {
+	requests := []reconcile.Request{}
+	users := &rabbitv1beta1.RabbitUserList{}
+	err := wm.client.List(context.Background(), users, client.InNamespace(obj.Meta.GetNamespace()))
+	if err != nil {
+		wm.log.Error(err, "error listing users")
+		// TODO Metric to track this for alerting.
+		return requests
+	}
+	for _, user := range users.Items {
+		if user.Spec.PasswordSecretRef != nil && user.Spec.PasswordSecretRef.Name == obj.Meta.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      user.Name,
+					Namespace: user.Namespace,
+				},
+			})
+		}
+	}
+	return requests
+}
+
 func (comp *userComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 	obj := ctx.Object.(*rabbitv1beta1.RabbitUser)
 	ctx.Conditions.SetUnknown("UserReady", "Unknown")
+	startOwnershipCheck(obj, userFinalizer, &obj.Status.Owned, &obj.Status.OwnershipConflict)
 
 	// Connect to the RabbitMQ server.
 	rmqc, uri, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
@@ -56,10 +109,25 @@ func (comp *userComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 	}
 
 	// Get the core data for the user from the object/context.
-	username := obj.Spec.Username
-	password, ok := ctx.Data.GetString("RABBIT_PASSWORD")
-	if !ok {
-		return cu.Result{}, errors.Wrap(err, "user password not set in context")
+	username := obj.ActiveUsername()
+	algorithm, err := userHashingAlgorithm(obj)
+	if err != nil {
+		return cu.Result{}, err
+	}
+	var password string
+	if obj.Spec.PasswordSecretRef != nil {
+		password, err = getSecretValue(ctx, ctx.Client, obj.Spec.PasswordSecretRef, obj.Namespace, "password")
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error getting password for user %s", username)
+		}
+		// Make sure the generated Secret gets this password and not the random one.
+		ctx.Data["RABBIT_PASSWORD"] = password
+	} else {
+		var ok bool
+		password, ok = ctx.Data.GetString("RABBIT_PASSWORD")
+		if !ok {
+			return cu.Result{}, errors.Wrap(err, "user password not set in context")
+		}
 	}
 
 	// Get the existing user data, if any.
@@ -73,34 +141,38 @@ func (comp *userComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 			return cu.Result{}, errors.Wrapf(err, "error getting user %s", username)
 		}
 	} else {
-		// Diff the existing user. TODO: This code is bad, it should probably sort and compare one by one?
-		existingTags := strings.Join(existingUser.Tags, ",")
-		if obj.Spec.Tags != existingTags {
-			updateUser = true
+		owner := ownerFromTags(existingUser.Tags)
+		if !checkOwnership(ctx, "User", fmt.Sprintf("RabbitMQ user %s", username), obj.Spec.Adopt, userFinalizer, &obj.Status.Owned, &obj.Status.OwnershipConflict, owner) {
+			return cu.Result{SkipRemaining: true}, nil
 		}
-		hashedPassword, err := hashRabbitPassword(password, existingUser.HashingAlgorithm, existingUser.PasswordHash)
-		if err != nil {
-			// ??? Should this actually error? It could just mark for update and let it get overwritten.
-			return cu.Result{}, errors.Wrap(err, "error hashing password for comparison")
+		// Diff the existing user, tag order doesn't matter.
+		if !sameTags(obj.ResolveTags(), withoutOwnerTags(existingUser.Tags)) || owner == "" {
+			updateUser = true
 		}
-		if hashedPassword != existingUser.PasswordHash {
+		// A different algorithm means a rehash no matter what the password is.
+		if existingUser.HashingAlgorithm != algorithm {
 			updateUser = true
+		} else {
+			hashedPassword, err := hashRabbitPassword(password, existingUser.HashingAlgorithm, existingUser.PasswordHash)
+			if err != nil {
+				// ??? Should this actually error? It could just mark for update and let it get overwritten.
+				return cu.Result{}, errors.Wrap(err, "error hashing password for comparison")
+			}
+			if hashedPassword != existingUser.PasswordHash {
+				updateUser = true
+			}
 		}
 	}
 
 	if createUser || updateUser {
 		// Always rehash even for an update so we get a new salt.
-		hashedPassword, err := hashRabbitPassword(password, DEFAULT_HASH_ALGORITHM, "")
+		hashedPassword, err := hashRabbitPassword(password, algorithm, "")
 		if err != nil {
 			return cu.Result{}, errors.Wrap(err, "error hashing password for put")
 		}
 
 		// Put the user, this will create or update depending on if the user already exists.
-		var tags rabbithole.UserTags
-		if obj.Spec.Tags != "" {
-			tags = strings.Split(obj.Spec.Tags, ",")
-		}
-		resp, err := rmqc.PutUser(username, rabbithole.UserSettings{PasswordHash: hashedPassword, HashingAlgorithm: DEFAULT_HASH_ALGORITHM, Tags: tags})
+		resp, err := rmqc.PutUser(username, rabbithole.UserSettings{PasswordHash: hashedPassword, HashingAlgorithm: algorithm, Tags: userTags(ctx, obj)})
 		if err != nil {
 			return cu.Result{}, errors.Wrapf(err, "error putting user %s", username)
 		}
@@ -122,6 +194,8 @@ func (comp *userComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 			eventMessage = "updated"
 		}
 		ctx.Events.Eventf(obj, "Normal", event, "RabbitMQ user %s %s", username, eventMessage)
+		obj.Status.Owned = true
+		obj.Status.OwnershipConflict = false
 
 		if resp.StatusCode == 201 {
 			// If this is the initial creation of the user reconcile again after 10 seconds
@@ -132,19 +206,15 @@ func (comp *userComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 	}
 
 	// Stash a URI to be inserted into the Secret in a template component later.
-	if uri.Scheme == "http" {
-		uri.Scheme = "amqp"
-	} else if uri.Scheme == "https" {
-		uri.Scheme = "amqps"
-	}
-	// Strip off the port so it uses the default AMQP port.
-	uri.Host = uri.Hostname()
+	uri = amqpURI(uri)
 	uri.User = url.UserPassword(username, password)
 	ctx.Data["uri"] = uri
 	ctx.Data["username"] = username
+	ctx.Data["port"] = amqpPort(uri)
 	// If the user only has perms on one vhost, populate the RABBIT_URL_VHOST value for convenience.
 	if len(obj.Spec.Permissions) == 1 && obj.Spec.Permissions[0].Vhost != "*" {
 		vhost := obj.Spec.Permissions[0].Vhost
+		ctx.Data["vhostName"] = vhost
 		if vhost != "/" {
 			vhost = "/" + vhost
 		}
@@ -158,6 +228,22 @@ func (comp *userComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 
 func (comp *userComponent) Finalize(ctx *cu.Context) (cu.Result, bool, error) {
 	obj := ctx.Object.(*rabbitv1beta1.RabbitUser)
+	username := obj.ActiveUsername()
+	// A user still being retired after a password rotation goes along with the active one.
+	usernames := []string{username}
+	if obj.Status.PasswordRotation != nil && obj.Status.PasswordRotation.RetiringUsername != "" {
+		usernames = append(usernames, obj.Status.PasswordRotation.RetiringUsername)
+	}
+
+	// Never delete a user this object didn't create or adopt.
+	if !obj.Status.Owned {
+		return cu.Result{}, true, nil
+	}
+
+	if obj.Spec.DeletionPolicy == "Retain" {
+		ctx.Events.Eventf(obj, "Normal", "UserRetained", "RabbitMQ user %s retained", username)
+		return cu.Result{}, true, nil
+	}
 
 	// Connect to the RabbitMQ server.
 	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
@@ -165,16 +251,74 @@ func (comp *userComponent) Finalize(ctx *cu.Context) (cu.Result, bool, error) {
 		return cu.Result{}, false, errors.Wrapf(err, "error connecting to rabbitmq")
 	}
 
-	_, err = rmqc.DeleteUser(obj.Spec.Username)
-	if err != nil {
-		return cu.Result{}, false, errors.Wrapf(err, "error deleting rabbitmq user %s", obj.Spec.Username)
+	// Users don't hold messages, so for them empty means no open connections.
+	if obj.Spec.DeletionPolicy == "DeleteIfEmpty" {
+		connections, err := rmqc.ListConnections()
+		if err != nil {
+			return cu.Result{}, false, errors.Wrap(err, "error listing connections")
+		}
+		open := 0
+		for _, conn := range connections {
+			for _, u := range usernames {
+				if conn.User == u {
+					open++
+				}
+			}
+		}
+		if open != 0 {
+			return deletionBlocked(ctx, "UserReady", "RabbitMQ user %s still has %d open connections", username, open), false, nil
+		}
+	}
+
+	for _, u := range usernames {
+		_, err = rmqc.DeleteUser(u)
+		if err != nil {
+			return cu.Result{}, false, errors.Wrapf(err, "error deleting rabbitmq user %s", u)
+		}
 	}
 	return cu.Result{}, true, nil
 }
 
+// Tags to put on a user, including the owner tag.
+func userTags(ctx *cu.Context, obj *rabbitv1beta1.RabbitUser) rabbithole.UserTags {
+	return withOwnerTag(ctx, obj.ResolveTags())
+}
+
+// Compare two lists of tags as sets.
+func sameTags(a, b []string) bool {
+	setA := map[string]bool{}
+	for _, tag := range a {
+		setA[tag] = true
+	}
+	setB := map[string]bool{}
+	for _, tag := range b {
+		setB[tag] = true
+	}
+	if len(setA) != len(setB) {
+		return false
+	}
+	for tag := range setA {
+		if !setB[tag] {
+			return false
+		}
+	}
+	return true
+}
+
 var hashAlgorithms = map[rabbithole.HashingAlgorithm]func() hash.Hash{
 	rabbithole.HashingAlgorithmSHA256: sha256.New,
 	rabbithole.HashingAlgorithmSHA512: sha512.New,
+	rabbithole.HashingAlgorithmMD5:    md5.New,
+}
+
+// Get the RabbitMQ hashing algorithm for a user from its spec or the operator default.
+func userHashingAlgorithm(obj *rabbitv1beta1.RabbitUser) (rabbithole.HashingAlgorithm, error) {
+	name := obj.GetHashingAlgorithm()
+	algorithm, ok := hashingAlgorithmNames[name]
+	if !ok {
+		return "", errors.Errorf("unknown hashing algorithm %s", name)
+	}
+	return algorithm, nil
 }
 
 func hashRabbitPassword(password string, algorithm rabbithole.HashingAlgorithm, existingHash string) (string, error) {
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	cu "github.com/coderanger/controller-utils"
+	"github.com/pkg/errors"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+type userLimitsComponent struct {
+	clientFactory rabbitClientFactory
+}
+
+func UserLimits() *userLimitsComponent {
+	return &userLimitsComponent{clientFactory: rabbitholeClientFactory}
+}
+
+func (comp *userLimitsComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitUser)
+	ctx.Conditions.SetUnknown("LimitsReady", "Unknown")
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	// Get the core data for the user from the object/context.
+	username := obj.ActiveUsername()
+
+	// Convert the spec limits into the names used by the management API.
+	desiredLimits := map[string]int{}
+	if obj.Spec.Limits != nil {
+		if obj.Spec.Limits.MaxConnections != nil {
+			desiredLimits["max-connections"] = *obj.Spec.Limits.MaxConnections
+		}
+		if obj.Spec.Limits.MaxChannels != nil {
+			desiredLimits["max-channels"] = *obj.Spec.Limits.MaxChannels
+		}
+	}
+
+	existingLimits, err := rmqc.GetUserLimits(username)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error fetching limits for user %s", username)
+	}
+
+	// Set any missing or changed limits.
+	for name, value := range desiredLimits {
+		existingValue, ok := existingLimits[name]
+		if ok && existingValue == value {
+			continue
+		}
+		_, err = rmqc.PutUserLimit(username, name, value)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error setting limit %s for user %s", name, username)
+		}
+		ctx.Events.Eventf(obj, "Normal", "LimitSet", "RabbitMQ limit %s for user %s set to %d", name, username, value)
+	}
+
+	// Clear any limits no longer in the spec.
+	for name := range existingLimits {
+		_, ok := desiredLimits[name]
+		if ok {
+			continue
+		}
+		_, err = rmqc.DeleteUserLimit(username, name)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error clearing limit %s for user %s", name, username)
+		}
+		ctx.Events.Eventf(obj, "Normal", "LimitCleared", "RabbitMQ limit %s for user %s cleared", name, username)
+	}
+
+	// Stash the active limits for the Secret template, -1 is unlimited so it isn't active.
+	activeLimits := []string{}
+	for name, value := range desiredLimits {
+		if value == -1 {
+			continue
+		}
+		activeLimits = append(activeLimits, fmt.Sprintf("%s=%d", name, value))
+		switch name {
+		case "max-connections":
+			ctx.Data["maxConnections"] = strconv.Itoa(value)
+		case "max-channels":
+			ctx.Data["maxChannels"] = strconv.Itoa(value)
+		}
+	}
+
+	if len(activeLimits) == 0 {
+		ctx.Conditions.SetfTrue("LimitsReady", "NoLimits", "RabbitMQ user %s has no limits", username)
+	} else {
+		sort.Strings(activeLimits)
+		ctx.Conditions.SetfTrue("LimitsReady", "LimitsActive", "RabbitMQ user %s limited to %s", username, strings.Join(activeLimits, ", "))
+	}
+	return cu.Result{}, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	cu "github.com/coderanger/controller-utils"
+	. "github.com/coderanger/controller-utils/tests/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("UserLimits component", func() {
+	var obj *rabbitv1beta1.RabbitUser
+	var rabbit *fakeRabbitClient
+	var helper *cu.UnitHelper
+
+	BeforeEach(func() {
+		rabbit = newFakeRabbitClient()
+		comp := UserLimits()
+		comp.clientFactory = rabbit.Factory
+		obj = &rabbitv1beta1.RabbitUser{
+			Spec: rabbitv1beta1.RabbitUserSpec{
+				Connection: rabbitv1beta1.RabbitConnection{
+					Host:     "testhost",
+					Username: "testuser",
+				},
+			},
+		}
+		helper = suiteHelper.Setup(comp, obj)
+	})
+
+	It("sets limits", func() {
+		maxConnections := 10
+		maxChannels := 50
+		obj.Spec.Limits = &rabbitv1beta1.RabbitUserLimits{MaxConnections: &maxConnections, MaxChannels: &maxChannels}
+		helper.MustReconcile()
+		Expect(rabbit.UserLimits).To(MatchAllKeys(Keys{
+			"testing": MatchAllKeys(Keys{
+				"max-connections": Equal(10),
+				"max-channels":    Equal(50),
+			}),
+		}))
+		Expect(helper.Ctx.Data).To(HaveKeyWithValue("maxConnections", "10"))
+		Expect(helper.Ctx.Data).To(HaveKeyWithValue("maxChannels", "50"))
+		Expect(obj).To(HaveCondition("LimitsReady").WithStatus("True").WithReason("LimitsActive"))
+	})
+
+	It("does not report unlimited values as active", func() {
+		unlimited := -1
+		obj.Spec.Limits = &rabbitv1beta1.RabbitUserLimits{MaxChannels: &unlimited}
+		helper.MustReconcile()
+		Expect(rabbit.UserLimits).To(MatchAllKeys(Keys{
+			"testing": MatchAllKeys(Keys{
+				"max-channels": Equal(-1),
+			}),
+		}))
+		Expect(helper.Ctx.Data).ToNot(HaveKey("maxChannels"))
+		Expect(obj).To(HaveCondition("LimitsReady").WithStatus("True").WithReason("NoLimits"))
+	})
+
+	It("updates a changed limit", func() {
+		maxChannels := 50
+		obj.Spec.Limits = &rabbitv1beta1.RabbitUserLimits{MaxChannels: &maxChannels}
+		rabbit.UserLimits["testing"] = map[string]int{"max-channels": 5}
+		helper.MustReconcile()
+		Expect(rabbit.UserLimits["testing"]).To(HaveKeyWithValue("max-channels", 50))
+		Expect(helper.Events).To(Receive(Equal("Normal LimitSet RabbitMQ limit max-channels for user testing set to 50")))
+	})
+
+	It("clears removed limits", func() {
+		rabbit.UserLimits["testing"] = map[string]int{"max-connections": 10}
+		helper.MustReconcile()
+		Expect(rabbit.UserLimits).To(MatchAllKeys(Keys{
+			"testing": BeEmpty(),
+		}))
+		Expect(helper.Events).To(Receive(Equal("Normal LimitCleared RabbitMQ limit max-connections for user testing cleared")))
+		Expect(obj).To(HaveCondition("LimitsReady").WithStatus("True").WithReason("NoLimits"))
+	})
+})
@@ -26,16 +26,23 @@ import (
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
 	"github.com/onsi/gomega/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
 )
 
 type matchRabbitPasswordMatcher struct {
-	expected string
+	expected  string
+	algorithm rabbithole.HashingAlgorithm
 }
 
 func MatchRabbitPassword(password string) types.GomegaMatcher {
-	return &matchRabbitPasswordMatcher{expected: password}
+	return &matchRabbitPasswordMatcher{expected: password, algorithm: rabbithole.HashingAlgorithmSHA256}
+}
+
+func MatchRabbitPasswordWith(password string, algorithm rabbithole.HashingAlgorithm) types.GomegaMatcher {
+	return &matchRabbitPasswordMatcher{expected: password, algorithm: algorithm}
 }
 
 func (matcher *matchRabbitPasswordMatcher) Match(actual interface{}) (bool, error) {
@@ -43,7 +50,7 @@ func (matcher *matchRabbitPasswordMatcher) Match(actual interface{}) (bool, erro
 	if !ok {
 		return false, fmt.Errorf("MatchRabbitPassword matcher expects a string")
 	}
-	hash2, err := hashRabbitPassword(matcher.expected, rabbithole.HashingAlgorithmSHA256, hash)
+	hash2, err := hashRabbitPassword(matcher.expected, matcher.algorithm, hash)
 	if err != nil {
 		return false, err
 	}
@@ -68,6 +75,7 @@ var _ = Describe("User component", func() {
 		comp := User()
 		comp.clientFactory = rabbit.Factory
 		obj = &rabbitv1beta1.RabbitUser{
+			ObjectMeta: metav1.ObjectMeta{UID: "testing-uid"},
 			Spec: rabbitv1beta1.RabbitUserSpec{
 				Connection: rabbitv1beta1.RabbitConnection{
 					Host:     "testhost",
@@ -83,7 +91,7 @@ var _ = Describe("User component", func() {
 		helper.MustReconcile()
 		Expect(rabbit.Users).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
 			"Name": Equal("testing"),
-			"Tags": BeEmpty(),
+			"Tags": ConsistOf("rabbitmq-operator-owner:testing-uid"),
 		}))))
 		Expect(helper.Events).To(Receive(Equal("Normal UserCreated RabbitMQ user testing created")))
 		Expect(obj).To(HaveCondition("UserReady").WithStatus("False").WithReason("UserPending"))
@@ -103,13 +111,13 @@ var _ = Describe("User component", func() {
 		helper.MustReconcile()
 		Expect(rabbit.Users).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
 			"Name": Equal("other"),
-			"Tags": BeEmpty(),
+			"Tags": ConsistOf("rabbitmq-operator-owner:testing-uid"),
 		}))))
 		Expect(helper.Events).To(Receive(Equal("Normal UserCreated RabbitMQ user other created")))
 	})
 
 	It("applies the Tags field", func() {
-		obj.Spec.Tags = "administrator"
+		obj.Spec.TagList = []string{"administrator"}
 		helper.MustReconcile()
 		Expect(rabbit.Users).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
 			"Name": Equal("testing"),
@@ -118,6 +126,76 @@ var _ = Describe("User component", func() {
 		Expect(helper.Events).To(Receive(Equal("Normal UserCreated RabbitMQ user testing created")))
 	})
 
+	It("applies the HashingAlgorithm field", func() {
+		obj.Spec.HashingAlgorithm = "sha512"
+		helper.MustReconcile()
+		Expect(rabbit.Users).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
+			"PasswordHash":     MatchRabbitPasswordWith("supersecret", rabbithole.HashingAlgorithmSHA512),
+			"HashingAlgorithm": Equal(rabbithole.HashingAlgorithmSHA512),
+		}))))
+	})
+
+	It("uses the operator default hashing algorithm", func() {
+		rabbitv1beta1.DefaultHashingAlgorithm = "md5"
+		defer func() { rabbitv1beta1.DefaultHashingAlgorithm = "sha256" }()
+		helper.MustReconcile()
+		Expect(rabbit.Users).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
+			"PasswordHash":     MatchRabbitPasswordWith("supersecret", rabbithole.HashingAlgorithmMD5),
+			"HashingAlgorithm": Equal(rabbithole.HashingAlgorithmMD5),
+		}))))
+	})
+
+	It("rehashes an existing user with a different algorithm", func() {
+		rabbit.Users = []*rabbithole.UserInfo{
+			{
+				Name:             "testing",
+				Tags:             rabbithole.UserTags{"rabbitmq-operator-owner:testing-uid"},
+				PasswordHash:     "KDYrITM0cP6OZ4+ZoB0+T1SY9Ro1hbOgH4iiaPbLAAoPb0Xn", // Hash("supersecret")
+				HashingAlgorithm: rabbithole.HashingAlgorithmSHA256,
+			},
+		}
+		obj.Spec.HashingAlgorithm = "sha512"
+		helper.MustReconcile()
+		Expect(rabbit.Users).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
+			"PasswordHash":     MatchRabbitPasswordWith("supersecret", rabbithole.HashingAlgorithmSHA512),
+			"HashingAlgorithm": Equal(rabbithole.HashingAlgorithmSHA512),
+		}))))
+		Expect(helper.Events).To(Receive(Equal("Normal UserUpdated RabbitMQ user testing updated")))
+	})
+
+	It("takes the password from a secret", func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "vault-password", Namespace: "default"},
+			Data: map[string][]byte{
+				"password": []byte("fromvault"),
+			},
+		}
+		helper.TestClient.Create(secret)
+		obj.Spec.PasswordSecretRef = &rabbitv1beta1.SecretRef{Name: "vault-password"}
+		helper.MustReconcile()
+		Expect(rabbit.Users).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Name":         Equal("testing"),
+			"PasswordHash": MatchRabbitPassword("fromvault"),
+		}))))
+		Expect(helper.Ctx.Data["RABBIT_PASSWORD"]).To(Equal("fromvault"))
+		Expect(helper.Events).To(Receive(Equal("Normal UserCreated RabbitMQ user testing created")))
+
+		// Changing the secret updates the user.
+		secret.Data["password"] = []byte("rotatedbyvault")
+		helper.TestClient.Update(secret)
+		helper.MustReconcile()
+		Expect(rabbit.Users).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
+			"PasswordHash": MatchRabbitPassword("rotatedbyvault"),
+		}))))
+		Expect(helper.Events).To(Receive(Equal("Normal UserUpdated RabbitMQ user testing updated")))
+	})
+
+	It("fails without the password secret", func() {
+		obj.Spec.PasswordSecretRef = &rabbitv1beta1.SecretRef{Name: "vault-password"}
+		_, err := helper.Reconcile()
+		Expect(err).To(MatchError(ContainSubstring("error getting password for user testing")))
+	})
+
 	It("applies the password value", func() {
 		helper.Ctx.Data["RABBIT_PASSWORD"] = "extrasecret"
 		helper.MustReconcile()
@@ -133,6 +211,30 @@ var _ = Describe("User component", func() {
 		rabbit.Users = []*rabbithole.UserInfo{
 			{
 				Name:             "testing",
+				Tags:             rabbithole.UserTags{"rabbitmq-operator-owner:testing-uid"},
+				PasswordHash:     "KDYrITM0cP6OZ4+ZoB0+T1SY9Ro1hbOgH4iiaPbLAAoPb0Xn", // Hash("supersecret")
+				HashingAlgorithm: rabbithole.HashingAlgorithmSHA256,
+			},
+		}
+		helper.MustReconcile()
+		Expect(helper.Events).ToNot(Receive())
+	})
+
+	It("combines the deprecated tags string with the tag list", func() {
+		obj.Spec.Tags = "monitoring"
+		obj.Spec.TagList = []string{"management"}
+		helper.MustReconcile()
+		Expect(rabbit.Users).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Tags": ConsistOf("monitoring", "management", "rabbitmq-operator-owner:testing-uid"),
+		}))))
+	})
+
+	It("does not update an existing user with tags in a different order", func() {
+		obj.Spec.TagList = []string{"monitoring", "management"}
+		rabbit.Users = []*rabbithole.UserInfo{
+			{
+				Name:             "testing",
+				Tags:             rabbithole.UserTags{"management", "rabbitmq-operator-owner:testing-uid", "monitoring"},
 				PasswordHash:     "KDYrITM0cP6OZ4+ZoB0+T1SY9Ro1hbOgH4iiaPbLAAoPb0Xn", // Hash("supersecret")
 				HashingAlgorithm: rabbithole.HashingAlgorithmSHA256,
 			},
@@ -145,6 +247,7 @@ var _ = Describe("User component", func() {
 		rabbit.Users = []*rabbithole.UserInfo{
 			{
 				Name:             "testing",
+				Tags:             rabbithole.UserTags{"rabbitmq-operator-owner:testing-uid"},
 				PasswordHash:     "vL4eIulfhM6xfHfWRLexc8y2dmCwSuDVc2ex2FWkwmKip4kX", // Hash("other")
 				HashingAlgorithm: rabbithole.HashingAlgorithmSHA256,
 			},
@@ -159,11 +262,11 @@ var _ = Describe("User component", func() {
 	})
 
 	It("updates an existing user with the wrong tags", func() {
-		obj.Spec.Tags = "monitoring"
+		obj.Spec.TagList = []string{"monitoring"}
 		rabbit.Users = []*rabbithole.UserInfo{
 			{
 				Name:             "testing",
-				Tags:             rabbithole.UserTags{"viewer"},
+				Tags:             rabbithole.UserTags{"viewer", "rabbitmq-operator-owner:testing-uid"},
 				PasswordHash:     "KDYrITM0cP6OZ4+ZoB0+T1SY9Ro1hbOgH4iiaPbLAAoPb0Xn", // Hash("supersecret")
 				HashingAlgorithm: rabbithole.HashingAlgorithmSHA256,
 			},
@@ -182,11 +285,150 @@ var _ = Describe("User component", func() {
 				Name: "testing",
 			},
 		}
+		obj.Status.Owned = true
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Users).To(BeEmpty())
+	})
+
+	It("deletes a user still being retired after a password rotation", func() {
+		rabbit.Users = []*rabbithole.UserInfo{
+			{Name: "testing"},
+			{Name: "testing-shadow"},
+		}
+		obj.Status.Owned = true
+		obj.Status.PasswordRotation = &rabbitv1beta1.RabbitUserPasswordRotation{
+			Phase:            "Retiring",
+			Shadow:           true,
+			RetiringUsername: "testing",
+		}
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Users).To(BeEmpty())
+	})
+
+	It("retains a user with the Retain policy", func() {
+		obj.Spec.DeletionPolicy = "Retain"
+		rabbit.Users = []*rabbithole.UserInfo{
+			{
+				Name: "testing",
+			},
+		}
+		obj.Status.Owned = true
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Users).To(HaveLen(1))
+		Expect(helper.Events).To(Receive(Equal("Normal UserRetained RabbitMQ user testing retained")))
+	})
+
+	It("blocks deleting a connected user with the DeleteIfEmpty policy", func() {
+		obj.Spec.DeletionPolicy = "DeleteIfEmpty"
+		rabbit.Users = []*rabbithole.UserInfo{
+			{
+				Name: "testing",
+			},
+		}
+		rabbit.Connections = []*rabbithole.ConnectionInfo{
+			{
+				Name: "127.0.0.1:1234 -> 127.0.0.1:5672",
+				User: "testing",
+			},
+			{
+				Name: "127.0.0.1:1235 -> 127.0.0.1:5672",
+				User: "other",
+			},
+		}
+		obj.Status.Owned = true
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeFalse())
+		Expect(rabbit.Users).To(HaveLen(1))
+		Expect(obj).To(HaveCondition("UserReady").WithStatus("False").WithReason("DeletionBlocked"))
+		Expect(helper.Events).To(Receive(Equal("Warning DeletionBlocked RabbitMQ user testing still has 1 open connections")))
+	})
+
+	It("deletes a disconnected user with the DeleteIfEmpty policy", func() {
+		obj.Spec.DeletionPolicy = "DeleteIfEmpty"
+		rabbit.Users = []*rabbithole.UserInfo{
+			{
+				Name: "testing",
+			},
+		}
+		rabbit.Connections = []*rabbithole.ConnectionInfo{
+			{
+				Name: "127.0.0.1:1235 -> 127.0.0.1:5672",
+				User: "other",
+			},
+		}
+		obj.Status.Owned = true
 		_, done := helper.MustFinalize()
 		Expect(done).To(BeTrue())
 		Expect(rabbit.Users).To(BeEmpty())
 	})
 
+	It("refuses to manage an existing user", func() {
+		rabbit.Users = []*rabbithole.UserInfo{
+			{
+				Name: "testing",
+			},
+		}
+		res := helper.MustReconcile()
+		Expect(res.SkipRemaining).To(BeTrue())
+		Expect(rabbit.Users[0].Tags).To(BeEmpty())
+		Expect(obj).To(HaveCondition("UserReady").WithStatus("False").WithReason("AlreadyExists"))
+	})
+
+	It("treats a user from before ownership tracking as owned", func() {
+		obj.Finalizers = []string{userFinalizer}
+		rabbit.Users = []*rabbithole.UserInfo{
+			{
+				Name: "testing",
+			},
+		}
+		helper.MustReconcile()
+		Expect(obj.Status.Owned).To(BeTrue())
+		Expect(rabbit.Users[0].Tags).To(ContainElement("rabbitmq-operator-owner:testing-uid"))
+	})
+
+	It("adopts an existing user", func() {
+		obj.Spec.Adopt = true
+		rabbit.Users = []*rabbithole.UserInfo{
+			{
+				Name:             "testing",
+				PasswordHash:     "KDYrITM0cP6OZ4+ZoB0+T1SY9Ro1hbOgH4iiaPbLAAoPb0Xn", // Hash("supersecret")
+				HashingAlgorithm: rabbithole.HashingAlgorithmSHA256,
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Users[0].Tags).To(ConsistOf("rabbitmq-operator-owner:testing-uid"))
+		Expect(obj.Status.Owned).To(BeTrue())
+		Expect(helper.Events).To(Receive(Equal("Normal UserAdopted RabbitMQ user testing adopted")))
+		Expect(helper.Events).To(Receive(Equal("Normal UserUpdated RabbitMQ user testing updated")))
+	})
+
+	It("refuses to adopt a user owned by another object", func() {
+		obj.Spec.Adopt = true
+		rabbit.Users = []*rabbithole.UserInfo{
+			{
+				Name: "testing",
+				Tags: rabbithole.UserTags{"rabbitmq-operator-owner:other-uid"},
+			},
+		}
+		helper.MustReconcile()
+		Expect(obj.Status.Owned).To(BeFalse())
+		Expect(obj).To(HaveCondition("UserReady").WithStatus("False").WithReason("OwnedByOther"))
+	})
+
+	It("does not delete a user it does not own", func() {
+		rabbit.Users = []*rabbithole.UserInfo{
+			{
+				Name: "testing",
+			},
+		}
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Users).To(HaveLen(1))
+	})
+
 	It("sets Data.vhost when permissions for only one vhost are set", func() {
 		obj.Spec.Permissions = []rabbitv1beta1.RabbitPermission{
 			{
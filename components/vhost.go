@@ -18,9 +18,14 @@ limitations under the License.
 package components
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	cu "github.com/coderanger/controller-utils"
 	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
 	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
 
 	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
 )
@@ -33,9 +38,14 @@ func Vhost() *vhostComponent {
 	return &vhostComponent{clientFactory: rabbitholeClientFactory}
 }
 
+func (comp *vhostComponent) Setup(ctx *cu.Context, bldr *ctrl.Builder) error {
+	return watchClusters(ctx, bldr)
+}
+
 func (comp *vhostComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 	obj := ctx.Object.(*rabbitv1beta1.RabbitVhost)
 	ctx.Conditions.SetUnknown("VhostReady", "Unknown")
+	startOwnershipCheck(obj, vhostFinalizer, &obj.Status.Owned, &obj.Status.OwnershipConflict)
 
 	// Connect to the RabbitMQ server.
 	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
@@ -46,9 +56,16 @@ func (comp *vhostComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 	// Get the core data for the vhost from the object/context.
 	vhost := obj.Spec.VhostName
 
-	// Check if the vhost already exists. There is nothing to update since there's no secondary values (for now, maybe tracing later).
-	var createVhost bool
-	_, err = rmqc.GetVhost(vhost)
+	settings := vhostSettings{
+		Description:      obj.Spec.Description,
+		Tags:             strings.Join(withOwnerTag(ctx, obj.Spec.Tags), ","),
+		DefaultQueueType: obj.Spec.DefaultQueueType,
+		Tracing:          obj.Spec.Tracing,
+	}
+
+	// Check if the vhost already exists and if so, if the metadata matches.
+	var createVhost, updateVhost bool
+	existingVhost, err := rmqc.GetVhost(vhost)
 	if err != nil {
 		rabbitErr, ok := err.(rabbithole.ErrorResponse)
 		if ok && rabbitErr.StatusCode == 404 {
@@ -56,11 +73,35 @@ func (comp *vhostComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 		} else {
 			return cu.Result{}, errors.Wrapf(err, "error getting vhost %s", vhost)
 		}
+	} else {
+		description := fmt.Sprintf("RabbitMQ vhost %s", vhost)
+		if !checkOwnership(ctx, "Vhost", description, obj.Spec.Adopt, vhostFinalizer, &obj.Status.Owned, &obj.Status.OwnershipConflict, ownerFromTags(existingVhost.Tags)) {
+			return cu.Result{SkipRemaining: true}, nil
+		}
+		// Adopted vhosts get the owner tag added.
+		if ownerFromTags(existingVhost.Tags) == "" {
+			updateVhost = true
+		}
+		if existingVhost.Description != obj.Spec.Description || existingVhost.Tracing != obj.Spec.Tracing {
+			updateVhost = true
+		}
+		// Tag order doesn't matter to RabbitMQ.
+		existingTags := withoutOwnerTags(existingVhost.Tags)
+		desiredTags := append([]string{}, obj.Spec.Tags...)
+		sort.Strings(existingTags)
+		sort.Strings(desiredTags)
+		if strings.Join(existingTags, ",") != strings.Join(desiredTags, ",") {
+			updateVhost = true
+		}
+		// An unset default queue type leaves whatever the broker has alone.
+		if obj.Spec.DefaultQueueType != "" && existingVhost.DefaultQueueType != obj.Spec.DefaultQueueType {
+			updateVhost = true
+		}
 	}
 
 	// Create the vhost if needed.
 	if createVhost {
-		resp, err := rmqc.PutVhost(vhost, rabbithole.VhostSettings{})
+		resp, err := rmqc.PutVhost(vhost, settings)
 		if err != nil {
 			return cu.Result{}, errors.Wrapf(err, "error creating vhost %s", vhost)
 		}
@@ -68,6 +109,17 @@ func (comp *vhostComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 			return cu.Result{}, errors.Errorf("unable to create vhost %s, got response code %v", vhost, resp.StatusCode)
 		}
 		ctx.Events.Eventf(obj, "Normal", "VhostCreated", "RabbitMQ vhost %s created", vhost)
+		obj.Status.Owned = true
+		obj.Status.OwnershipConflict = false
+	}
+
+	// Update the vhost metadata in place if needed.
+	if updateVhost {
+		_, err := rmqc.PutVhost(vhost, settings)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error updating vhost %s", vhost)
+		}
+		ctx.Events.Eventf(obj, "Normal", "VhostUpdated", "RabbitMQ vhost %s updated", vhost)
 	}
 
 	ctx.Conditions.SetfTrue("VhostReady", "VhostExists", "RabbitMQ vhost %s exists", vhost)
@@ -76,6 +128,17 @@ func (comp *vhostComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
 
 func (comp *vhostComponent) Finalize(ctx *cu.Context) (cu.Result, bool, error) {
 	obj := ctx.Object.(*rabbitv1beta1.RabbitVhost)
+	vhost := obj.Spec.VhostName
+
+	// Never delete a vhost this object didn't create or adopt.
+	if !obj.Status.Owned {
+		return cu.Result{}, true, nil
+	}
+
+	if obj.Spec.DeletionPolicy == "Retain" {
+		ctx.Events.Eventf(obj, "Normal", "VhostRetained", "RabbitMQ vhost %s retained", vhost)
+		return cu.Result{}, true, nil
+	}
 
 	// Connect to the RabbitMQ server.
 	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
@@ -83,7 +146,36 @@ func (comp *vhostComponent) Finalize(ctx *cu.Context) (cu.Result, bool, error) {
 		return cu.Result{}, false, errors.Wrapf(err, "error connecting to rabbitmq")
 	}
 
-	_, err = rmqc.DeleteVhost(obj.Spec.VhostName)
+	if obj.Spec.DeletionPolicy == "DeleteIfEmpty" {
+		existingVhost, err := rmqc.GetVhost(vhost)
+		if err != nil {
+			rabbitErr, ok := err.(rabbithole.ErrorResponse)
+			if ok && rabbitErr.StatusCode == 404 {
+				return cu.Result{}, true, nil
+			}
+			return cu.Result{}, false, errors.Wrapf(err, "error getting vhost %s", vhost)
+		}
+		if existingVhost.Messages != 0 {
+			return deletionBlocked(ctx, "VhostReady", "RabbitMQ vhost %s still has %d messages", vhost, existingVhost.Messages), false, nil
+		}
+
+		// The vhost total lags behind, so check each queue too before deleting anything. Nothing is deleted unless
+		// every queue is empty, anything published after this check would still be lost.
+		queues, err := rmqc.ListQueuesIn(vhost)
+		if err != nil {
+			rabbitErr, ok := err.(rabbithole.ErrorResponse)
+			if !ok || rabbitErr.StatusCode != 404 {
+				return cu.Result{}, false, errors.Wrapf(err, "error listing queues on vhost %s", vhost)
+			}
+		}
+		for _, queue := range queues {
+			if queue.Messages != 0 {
+				return deletionBlocked(ctx, "VhostReady", "RabbitMQ vhost %s queue %s still has %d messages", vhost, queue.Name, queue.Messages), false, nil
+			}
+		}
+	}
+
+	_, err = rmqc.DeleteVhost(vhost)
 	if err != nil {
 		return cu.Result{}, false, errors.Wrapf(err, "error deleting rabbitmq user %s", obj.Spec.VhostName)
 	}
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package components
+
+import (
+	cu "github.com/coderanger/controller-utils"
+	"github.com/pkg/errors"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+type vhostLimitsComponent struct {
+	clientFactory rabbitClientFactory
+}
+
+func VhostLimits() *vhostLimitsComponent {
+	return &vhostLimitsComponent{clientFactory: rabbitholeClientFactory}
+}
+
+func (comp *vhostLimitsComponent) Reconcile(ctx *cu.Context) (cu.Result, error) {
+	obj := ctx.Object.(*rabbitv1beta1.RabbitVhost)
+	ctx.Conditions.SetUnknown("LimitsReady", "Unknown")
+
+	// Connect to the RabbitMQ server.
+	rmqc, _, err := connect(ctx, &obj.Spec.Connection, obj.Namespace, ctx.Client, comp.clientFactory)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error connecting to rabbitmq")
+	}
+
+	// Get the core data for the vhost from the object/context.
+	vhost := obj.Spec.VhostName
+
+	// Convert the spec limits into the names used by the management API.
+	desiredLimits := map[string]int{}
+	if obj.Spec.Limits != nil {
+		if obj.Spec.Limits.MaxConnections != nil {
+			desiredLimits["max-connections"] = *obj.Spec.Limits.MaxConnections
+		}
+		if obj.Spec.Limits.MaxQueues != nil {
+			desiredLimits["max-queues"] = *obj.Spec.Limits.MaxQueues
+		}
+	}
+
+	existingLimits, err := rmqc.GetVhostLimits(vhost)
+	if err != nil {
+		return cu.Result{}, errors.Wrapf(err, "error fetching limits for vhost %s", vhost)
+	}
+
+	// Set any missing or changed limits.
+	for name, value := range desiredLimits {
+		existingValue, ok := existingLimits[name]
+		if ok && existingValue == value {
+			continue
+		}
+		_, err = rmqc.PutVhostLimit(vhost, name, value)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error setting limit %s for vhost %s", name, vhost)
+		}
+		ctx.Events.Eventf(obj, "Normal", "LimitSet", "RabbitMQ limit %s for vhost %s set to %d", name, vhost, value)
+	}
+
+	// Clear any limits no longer in the spec.
+	for name := range existingLimits {
+		_, ok := desiredLimits[name]
+		if ok {
+			continue
+		}
+		_, err = rmqc.DeleteVhostLimit(vhost, name)
+		if err != nil {
+			return cu.Result{}, errors.Wrapf(err, "error clearing limit %s for vhost %s", name, vhost)
+		}
+		ctx.Events.Eventf(obj, "Normal", "LimitCleared", "RabbitMQ limit %s for vhost %s cleared", name, vhost)
+	}
+
+	ctx.Conditions.SetTrue("LimitsReady", "LimitsSynced")
+	return cu.Result{}, nil
+}
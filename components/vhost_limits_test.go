@@ -0,0 +1,107 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package components
+
+import (
+	cu "github.com/coderanger/controller-utils"
+	. "github.com/coderanger/controller-utils/tests/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("VhostLimits component", func() {
+	var obj *rabbitv1beta1.RabbitVhost
+	var rabbit *fakeRabbitClient
+	var helper *cu.UnitHelper
+
+	BeforeEach(func() {
+		rabbit = newFakeRabbitClient()
+		comp := VhostLimits()
+		comp.clientFactory = rabbit.Factory
+		obj = &rabbitv1beta1.RabbitVhost{
+			Spec: rabbitv1beta1.RabbitVhostSpec{
+				Connection: rabbitv1beta1.RabbitConnection{
+					Host:     "testhost",
+					Username: "testuser",
+				},
+			},
+		}
+		helper = suiteHelper.Setup(comp, obj)
+	})
+
+	It("sets limits", func() {
+		maxConnections := 100
+		maxQueues := -1
+		obj.Spec.Limits = &rabbitv1beta1.RabbitVhostLimits{MaxConnections: &maxConnections, MaxQueues: &maxQueues}
+		helper.MustReconcile()
+		Expect(rabbit.VhostLimits).To(MatchAllKeys(Keys{
+			"testing": MatchAllKeys(Keys{
+				"max-connections": Equal(100),
+				"max-queues":      Equal(-1),
+			}),
+		}))
+		Expect(obj).To(HaveCondition("LimitsReady").WithStatus("True"))
+	})
+
+	It("updates a changed limit", func() {
+		maxConnections := 100
+		obj.Spec.Limits = &rabbitv1beta1.RabbitVhostLimits{MaxConnections: &maxConnections}
+		rabbit.VhostLimits["testing"] = map[string]int{"max-connections": 10}
+		helper.MustReconcile()
+		Expect(rabbit.VhostLimits).To(MatchAllKeys(Keys{
+			"testing": MatchAllKeys(Keys{
+				"max-connections": Equal(100),
+			}),
+		}))
+		Expect(helper.Events).To(Receive(Equal("Normal LimitSet RabbitMQ limit max-connections for vhost testing set to 100")))
+		Expect(obj).To(HaveCondition("LimitsReady").WithStatus("True"))
+	})
+
+	It("does nothing when limits match", func() {
+		maxQueues := 5
+		obj.Spec.Limits = &rabbitv1beta1.RabbitVhostLimits{MaxQueues: &maxQueues}
+		rabbit.VhostLimits["testing"] = map[string]int{"max-queues": 5}
+		helper.MustReconcile()
+		Expect(helper.Events).ToNot(Receive())
+		Expect(obj).To(HaveCondition("LimitsReady").WithStatus("True"))
+	})
+
+	It("clears removed limits", func() {
+		maxQueues := 5
+		obj.Spec.Limits = &rabbitv1beta1.RabbitVhostLimits{MaxQueues: &maxQueues}
+		rabbit.VhostLimits["testing"] = map[string]int{"max-connections": 10, "max-queues": 5}
+		helper.MustReconcile()
+		Expect(rabbit.VhostLimits).To(MatchAllKeys(Keys{
+			"testing": MatchAllKeys(Keys{
+				"max-queues": Equal(5),
+			}),
+		}))
+		Expect(helper.Events).To(Receive(Equal("Normal LimitCleared RabbitMQ limit max-connections for vhost testing cleared")))
+		Expect(obj).To(HaveCondition("LimitsReady").WithStatus("True"))
+	})
+
+	It("clears all limits when the block is removed", func() {
+		rabbit.VhostLimits["testing"] = map[string]int{"max-connections": 10}
+		helper.MustReconcile()
+		Expect(rabbit.VhostLimits).To(MatchAllKeys(Keys{
+			"testing": BeEmpty(),
+		}))
+		Expect(obj).To(HaveCondition("LimitsReady").WithStatus("True"))
+	})
+})
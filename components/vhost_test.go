@@ -24,6 +24,8 @@ import (
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
 )
 
@@ -37,6 +39,7 @@ var _ = Describe("Vhost component", func() {
 		comp := Vhost()
 		comp.clientFactory = rabbit.Factory
 		obj = &rabbitv1beta1.RabbitVhost{
+			ObjectMeta: metav1.ObjectMeta{UID: "testing-uid"},
 			Spec: rabbitv1beta1.RabbitVhostSpec{
 				Connection: rabbitv1beta1.RabbitConnection{
 					Host:     "testhost",
@@ -57,12 +60,231 @@ var _ = Describe("Vhost component", func() {
 	})
 
 	It("does not update an existing vhost with nothing to change", func() {
-		rabbit.Vhosts = []*rabbithole.VhostInfo{
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name: "testing",
+				Tags: []string{"rabbitmq-operator-owner:testing-uid"},
+			},
+		}
+		helper.MustReconcile()
+		Expect(helper.Events).ToNot(Receive())
+	})
+
+	It("creates a vhost with metadata", func() {
+		obj.Spec.Description = "Orders service"
+		obj.Spec.Tags = []string{"production", "orders"}
+		obj.Spec.DefaultQueueType = "quorum"
+		helper.MustReconcile()
+		Expect(rabbit.Vhosts).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Name":             Equal("testing"),
+			"Description":      Equal("Orders service"),
+			"Tags":             Equal([]string{"production", "orders", "rabbitmq-operator-owner:testing-uid"}),
+			"DefaultQueueType": Equal("quorum"),
+		}))))
+		Expect(helper.Events).To(Receive(Equal("Normal VhostCreated RabbitMQ vhost testing created")))
+	})
+
+	It("updates an existing vhost with different metadata", func() {
+		obj.Spec.Description = "Orders service"
+		obj.Spec.Tracing = true
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name:        "testing",
+				Description: "Old description",
+				Tags:        []string{"rabbitmq-operator-owner:testing-uid"},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Vhosts).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Name":        Equal("testing"),
+			"Description": Equal("Orders service"),
+			"Tracing":     BeTrue(),
+		}))))
+		Expect(helper.Events).To(Receive(Equal("Normal VhostUpdated RabbitMQ vhost testing updated")))
+		Expect(obj).To(HaveCondition("VhostReady").WithStatus("True").WithReason("VhostExists"))
+	})
+
+	It("does not update an existing vhost with reordered tags", func() {
+		obj.Spec.Tags = []string{"production", "orders"}
+		rabbit.Vhosts = []*vhostInfo{
 			{
 				Name: "testing",
+				Tags: []string{"orders", "rabbitmq-operator-owner:testing-uid", "production"},
+			},
+		}
+		helper.MustReconcile()
+		Expect(helper.Events).ToNot(Receive())
+	})
+
+	It("does not update an existing vhost when the default queue type is unset", func() {
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name:             "testing",
+				Tags:             []string{"rabbitmq-operator-owner:testing-uid"},
+				DefaultQueueType: "classic",
 			},
 		}
 		helper.MustReconcile()
 		Expect(helper.Events).ToNot(Receive())
 	})
+
+	It("deletes a vhost", func() {
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name:     "testing",
+				Messages: 10,
+			},
+		}
+		obj.Status.Owned = true
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Vhosts).To(BeEmpty())
+	})
+
+	It("retains a vhost with the Retain policy", func() {
+		obj.Spec.DeletionPolicy = "Retain"
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name: "testing",
+			},
+		}
+		obj.Status.Owned = true
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Vhosts).To(HaveLen(1))
+		Expect(helper.Events).To(Receive(Equal("Normal VhostRetained RabbitMQ vhost testing retained")))
+	})
+
+	It("blocks deleting a vhost with messages with the DeleteIfEmpty policy", func() {
+		obj.Spec.DeletionPolicy = "DeleteIfEmpty"
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name:     "testing",
+				Messages: 10,
+			},
+		}
+		obj.Status.Owned = true
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeFalse())
+		Expect(rabbit.Vhosts).To(HaveLen(1))
+		Expect(obj).To(HaveCondition("VhostReady").WithStatus("False").WithReason("DeletionBlocked"))
+		Expect(helper.Events).To(Receive(Equal("Warning DeletionBlocked RabbitMQ vhost testing still has 10 messages")))
+	})
+
+	It("blocks deleting a vhost with a non-empty queue with the DeleteIfEmpty policy", func() {
+		obj.Spec.DeletionPolicy = "DeleteIfEmpty"
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name: "testing",
+			},
+		}
+		// The vhost message count hasn't caught up yet.
+		rabbit.Queues["testing"] = map[string]*rabbithole.QueueInfo{
+			"empty": {Name: "empty", Vhost: "testing"},
+			"full":  {Name: "full", Vhost: "testing", Messages: 3},
+		}
+		obj.Status.Owned = true
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeFalse())
+		Expect(rabbit.Vhosts).To(HaveLen(1))
+		// Nothing is deleted until every queue is empty.
+		Expect(rabbit.Queues["testing"]).To(HaveLen(2))
+		Expect(obj).To(HaveCondition("VhostReady").WithStatus("False").WithReason("DeletionBlocked"))
+		Expect(helper.Events).To(Receive(Equal("Warning DeletionBlocked RabbitMQ vhost testing queue full still has 3 messages")))
+	})
+
+	It("deletes an empty vhost with the DeleteIfEmpty policy", func() {
+		obj.Spec.DeletionPolicy = "DeleteIfEmpty"
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name: "testing",
+			},
+		}
+		rabbit.Queues["testing"] = map[string]*rabbithole.QueueInfo{
+			"empty": {Name: "empty", Vhost: "testing"},
+		}
+		obj.Status.Owned = true
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Vhosts).To(BeEmpty())
+		Expect(rabbit.Queues["testing"]).To(BeEmpty())
+	})
+
+	It("refuses to manage an existing vhost", func() {
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name: "testing",
+			},
+		}
+		res := helper.MustReconcile()
+		Expect(res.SkipRemaining).To(BeTrue())
+		Expect(rabbit.Vhosts[0].Tags).To(BeEmpty())
+		Expect(obj.Status.Owned).To(BeFalse())
+		Expect(obj).To(HaveCondition("VhostReady").WithStatus("False").WithReason("AlreadyExists"))
+		Expect(helper.Events).ToNot(Receive())
+	})
+
+	It("treats a vhost from before ownership tracking as owned", func() {
+		obj.Finalizers = []string{vhostFinalizer}
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name: "testing",
+			},
+		}
+		helper.MustReconcile()
+		Expect(obj.Status.Owned).To(BeTrue())
+		Expect(rabbit.Vhosts[0].Tags).To(ConsistOf("rabbitmq-operator-owner:testing-uid"))
+		Expect(helper.Events).ToNot(Receive(ContainSubstring("Adopted")))
+	})
+
+	It("adopts an existing vhost", func() {
+		obj.Spec.Adopt = true
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name: "testing",
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Vhosts[0].Tags).To(ConsistOf("rabbitmq-operator-owner:testing-uid"))
+		Expect(obj.Status.Owned).To(BeTrue())
+		Expect(obj).To(HaveCondition("VhostReady").WithStatus("True").WithReason("VhostExists"))
+		Expect(helper.Events).To(Receive(Equal("Normal VhostAdopted RabbitMQ vhost testing adopted")))
+	})
+
+	It("adopts an existing vhost with the annotation", func() {
+		obj.Annotations = map[string]string{"rabbitmq.coderanger.net/adopt": "true"}
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name: "testing",
+			},
+		}
+		helper.MustReconcile()
+		Expect(obj.Status.Owned).To(BeTrue())
+		Expect(helper.Events).To(Receive(Equal("Normal VhostAdopted RabbitMQ vhost testing adopted")))
+	})
+
+	It("refuses to adopt a vhost owned by another object", func() {
+		obj.Spec.Adopt = true
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name: "testing",
+				Tags: []string{"rabbitmq-operator-owner:other-uid"},
+			},
+		}
+		helper.MustReconcile()
+		Expect(rabbit.Vhosts[0].Tags).To(ConsistOf("rabbitmq-operator-owner:other-uid"))
+		Expect(obj.Status.Owned).To(BeFalse())
+		Expect(obj).To(HaveCondition("VhostReady").WithStatus("False").WithReason("OwnedByOther"))
+	})
+
+	It("does not delete a vhost it does not own", func() {
+		rabbit.Vhosts = []*vhostInfo{
+			{
+				Name: "testing",
+			},
+		}
+		_, done := helper.MustFinalize()
+		Expect(done).To(BeTrue())
+		Expect(rabbit.Vhosts).To(HaveLen(1))
+	})
 })
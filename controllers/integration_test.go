@@ -73,7 +73,7 @@ var _ = Describe("Controller integration tests", func() {
 			ObjectMeta: metav1.ObjectMeta{Name: "testing"},
 			Spec: rabbitv1beta1.RabbitUserSpec{
 				Username: "testing-" + randstring.MustRandomString(5),
-				Tags:     "management",
+				TagList:  []string{"management"},
 				Permissions: []rabbitv1beta1.RabbitPermission{
 					{
 						Vhost:     vhost.Spec.VhostName,
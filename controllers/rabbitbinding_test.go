@@ -0,0 +1,86 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+
+	cu "github.com/coderanger/controller-utils"
+	"github.com/coderanger/controller-utils/randstring"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("RabbitBinding controller", func() {
+	var helper *cu.FunctionalHelper
+	var rmqc *rabbithole.Client
+
+	BeforeEach(func() {
+		helper = suiteHelper.MustStart(RabbitBinding)
+		rmqc = connect()
+	})
+
+	AfterEach(func() {
+		helper.MustStop()
+		helper = nil
+	})
+
+	It("runs a basic reconcile", func() {
+		c := helper.TestClient
+
+		binding := &rabbitv1beta1.RabbitBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing"},
+			Spec: rabbitv1beta1.RabbitBindingSpec{
+				Vhost:       "/",
+				Source:      "testing-" + randstring.MustRandomString(5),
+				Destination: "testing-" + randstring.MustRandomString(5),
+				RoutingKey:  "mykey",
+			},
+		}
+
+		// Create the exchange and queue to bind.
+		_, err := rmqc.DeclareExchange("/", binding.Spec.Source, rabbithole.ExchangeSettings{Type: "direct"})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = rmqc.DeclareQueue("/", binding.Spec.Destination, rabbithole.QueueSettings{})
+		Expect(err).ToNot(HaveOccurred())
+
+		c.Create(binding)
+		c.EventuallyGetName("testing", binding, c.EventuallyReady())
+		Expect(binding.Finalizers).To(ContainElement("rabbitbinding.rabbitmq.coderanger.net/binding"))
+
+		// Check that the binding exists
+		bindings, err := rmqc.ListQueueBindingsBetween("/", binding.Spec.Source, binding.Spec.Destination)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bindings).To(HaveLen(1))
+		Expect(bindings[0].RoutingKey).To(Equal("mykey"))
+
+		// Delete the binding and make sure it is cleaned up.
+		c.Delete(binding)
+		Eventually(func() bool {
+			err := helper.Client.Get(context.Background(), types.NamespacedName{Name: "testing", Namespace: helper.Namespace}, binding)
+			return err != nil && kerrors.IsNotFound(err)
+		}).Should(BeTrue())
+		bindings, err = rmqc.ListQueueBindingsBetween("/", binding.Spec.Source, binding.Spec.Destination)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bindings).To(BeEmpty())
+	})
+})
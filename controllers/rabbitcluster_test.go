@@ -0,0 +1,85 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strconv"
+
+	cu "github.com/coderanger/controller-utils"
+	"github.com/coderanger/controller-utils/randstring"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("RabbitCluster controller", func() {
+	var helper *cu.FunctionalHelper
+
+	BeforeEach(func() {
+		helper = suiteHelper.MustStart(RabbitCluster)
+	})
+
+	AfterEach(func() {
+		helper.MustStop()
+		helper = nil
+	})
+
+	It("runs a basic reconcile", func() {
+		c := helper.TestClient
+
+		// Build the cluster from the same server the other tests use.
+		parsedUri, err := url.Parse(os.Getenv("TEST_RABBITMQ"))
+		Expect(err).ToNot(HaveOccurred())
+		password, _ := parsedUri.User.Password()
+		port, _ := strconv.Atoi(parsedUri.Port())
+		c.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "rabbitmq-admin"},
+			StringData: map[string]string{
+				"password": password,
+			},
+		})
+
+		// Cluster-scoped, so skip the namespaced test client.
+		cluster := &rabbitv1beta1.RabbitCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing-" + randstring.MustRandomString(5)},
+			Spec: rabbitv1beta1.RabbitClusterSpec{
+				Protocol:          parsedUri.Scheme,
+				Host:              parsedUri.Hostname(),
+				Port:              port,
+				Username:          parsedUri.User.Username(),
+				PasswordSecretRef: &rabbitv1beta1.ClusterSecretRef{Name: "rabbitmq-admin", Namespace: helper.Namespace},
+			},
+		}
+		err = helper.Client.Create(context.Background(), cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(func() string {
+			err := helper.Client.Get(context.Background(), types.NamespacedName{Name: cluster.Name}, cluster)
+			Expect(err).ToNot(HaveOccurred())
+			return cluster.Status.RabbitMQVersion
+		}).ShouldNot(BeEmpty())
+
+		err = helper.Client.Delete(context.Background(), cluster)
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
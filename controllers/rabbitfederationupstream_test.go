@@ -0,0 +1,87 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	cu "github.com/coderanger/controller-utils"
+	"github.com/coderanger/controller-utils/randstring"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("RabbitFederationUpstream controller", func() {
+	var helper *cu.FunctionalHelper
+	var rmqc *rabbithole.Client
+
+	BeforeEach(func() {
+		helper = suiteHelper.MustStart(RabbitFederationUpstream)
+		rmqc = connect()
+	})
+
+	AfterEach(func() {
+		helper.MustStop()
+		helper = nil
+	})
+
+	It("runs a basic reconcile", func() {
+		c := helper.TestClient
+
+		// The upstream doesn't have to be reachable for the parameter to be created.
+		upstreamURI := "amqp://upstream-" + randstring.MustRandomString(5)
+		c.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing-upstream"},
+			Data: map[string][]byte{
+				"RABBIT_URL": []byte(upstreamURI),
+			},
+		})
+
+		upstream := &rabbitv1beta1.RabbitFederationUpstream{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing"},
+			Spec: rabbitv1beta1.RabbitFederationUpstreamSpec{
+				Vhost:        "/",
+				URISecretRef: rabbitv1beta1.SecretRef{Name: "testing-upstream"},
+			},
+		}
+
+		c.Create(upstream)
+		c.EventuallyGetName("testing", upstream, c.EventuallyReady())
+		Expect(upstream.Finalizers).To(ContainElement("rabbitfederationupstream.rabbitmq.coderanger.net/federationupstream"))
+
+		// Check that the upstream exists with the URI from the secret.
+		info, err := rmqc.GetFederationUpstream("/", "testing")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Definition.Uri).To(Equal(upstreamURI))
+
+		// Delete the upstream and make sure it is cleaned up.
+		c.Delete(upstream)
+		Eventually(func() bool {
+			err := helper.Client.Get(context.Background(), types.NamespacedName{Name: "testing", Namespace: helper.Namespace}, upstream)
+			return err != nil && kerrors.IsNotFound(err)
+		}).Should(BeTrue())
+		_, err = rmqc.GetFederationUpstream("/", "testing")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -31,6 +31,8 @@ func RabbitQueue(mgr ctrl.Manager) error {
 	return cu.NewReconciler(mgr).
 		For(&rabbitmqv1beta1.RabbitQueue{}).
 		Component("queue", components.Queue()).
+		Component("stats", components.QueueStats()).
+		Component("members", components.QueueMembers()).
 		ReadyStatusComponent("QueueReady").
 		Webhook().
 		Complete()
@@ -91,6 +91,7 @@ var _ = Describe("RabbitQueue controller", func() {
 				Vhost:     "/",
 				QueueName: "testing-" + randstring.MustRandomString(5),
 				Durable:   &durable,
+				Adopt:     true,
 			},
 		}
 
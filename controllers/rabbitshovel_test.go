@@ -0,0 +1,116 @@
+/*
+Copyright 2020 Noah Kantrowitz
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/url"
+	"os"
+
+	cu "github.com/coderanger/controller-utils"
+	"github.com/coderanger/controller-utils/randstring"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	rabbitv1beta1 "github.com/coderanger/rabbitmq-operator/api/v1beta1"
+)
+
+var _ = Describe("RabbitShovel controller", func() {
+	var helper *cu.FunctionalHelper
+	var rmqc *rabbithole.Client
+
+	BeforeEach(func() {
+		helper = suiteHelper.MustStart(RabbitShovel)
+		rmqc = connect()
+	})
+
+	AfterEach(func() {
+		helper.MustStop()
+		helper = nil
+	})
+
+	It("runs a basic reconcile", func() {
+		c := helper.TestClient
+
+		// A user of its own for the shovel to connect as, standing in for a RabbitUser.
+		username := "testing-" + randstring.MustRandomString(5)
+		password := randstring.MustRandomString(20)
+		_, err := rmqc.PutUser(username, rabbithole.UserSettings{Password: password, Tags: []string{}})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = rmqc.UpdatePermissionsIn("/", username, rabbithole.Permissions{Configure: ".*", Write: ".*", Read: ".*"})
+		Expect(err).ToNot(HaveOccurred())
+		defer rmqc.DeleteUser(username)
+		testURI, err := url.Parse(os.Getenv("TEST_RABBITMQ"))
+		Expect(err).ToNot(HaveOccurred())
+		userURI := &url.URL{Scheme: "amqp", Host: testURI.Hostname(), User: url.UserPassword(username, password)}
+		c.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing-rabbituser"},
+			Data: map[string][]byte{
+				"RABBIT_URL": []byte(userURI.String()),
+			},
+		})
+
+		shovel := &rabbitv1beta1.RabbitShovel{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing"},
+			Spec: rabbitv1beta1.RabbitShovelSpec{
+				Vhost: "/",
+				Source: rabbitv1beta1.RabbitShovelEndpoint{
+					UserSecretRef: &rabbitv1beta1.SecretRef{Name: "testing-rabbituser"},
+					Vhost:         "/",
+					Queue:         "testing-" + randstring.MustRandomString(5),
+				},
+				Destination: rabbitv1beta1.RabbitShovelEndpoint{
+					UserSecretRef: &rabbitv1beta1.SecretRef{Name: "testing-rabbituser"},
+					Vhost:         "/",
+					Queue:         "testing-" + randstring.MustRandomString(5),
+				},
+			},
+		}
+
+		// Create the queues on both ends.
+		_, err = rmqc.DeclareQueue("/", shovel.Spec.Source.Queue, rabbithole.QueueSettings{})
+		Expect(err).ToNot(HaveOccurred())
+		defer rmqc.DeleteQueue("/", shovel.Spec.Source.Queue)
+		_, err = rmqc.DeclareQueue("/", shovel.Spec.Destination.Queue, rabbithole.QueueSettings{})
+		Expect(err).ToNot(HaveOccurred())
+		defer rmqc.DeleteQueue("/", shovel.Spec.Destination.Queue)
+
+		c.Create(shovel)
+		c.EventuallyGetName("testing", shovel, c.EventuallyReady())
+		Expect(shovel.Finalizers).To(ContainElement("rabbitshovel.rabbitmq.coderanger.net/shovel"))
+
+		// Check that the shovel connects as the user and not with the operator's credentials.
+		info, err := rmqc.GetShovel("/", "testing")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Definition.SourceURI).To(Equal(userURI.String() + "/%2F"))
+		Expect(info.Definition.DestinationURI).To(Equal(userURI.String() + "/%2F"))
+
+		// Delete the shovel and make sure it is cleaned up.
+		c.Delete(shovel)
+		Eventually(func() bool {
+			err := helper.Client.Get(context.Background(), types.NamespacedName{Name: "testing", Namespace: helper.Namespace}, shovel)
+			return err != nil && kerrors.IsNotFound(err)
+		}).Should(BeTrue())
+		_, err = rmqc.GetShovel("/", "testing")
+		Expect(err).To(HaveOccurred())
+	})
+})
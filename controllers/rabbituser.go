@@ -27,7 +27,8 @@ import (
 
 // +kubebuilder:rbac:groups=rabbitmq.coderanger.net,resources=rabbitusers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=rabbitmq.coderanger.net,resources=rabbitusers/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 
 func RabbitUser(mgr ctrl.Manager) error {
 	return cu.NewReconciler(mgr).
@@ -35,9 +36,13 @@ func RabbitUser(mgr ctrl.Manager) error {
 		Templates(templates.Templates).
 		RandomSecretComponent("RABBIT_PASSWORD").
 		Component("user", components.User()).
+		Component("passwordrotation", components.PasswordRotation()).
 		Component("permissions", components.Permissions()).
+		Component("topicpermissions", components.TopicPermissions()).
+		Component("limits", components.UserLimits()).
 		TemplateComponent("user_secret.yml", "").
-		ReadyStatusComponent("UserReady", "PermissionsReady").
+		Component("secrettemplate", components.SecretTemplate()).
+		ReadyStatusComponent("UserReady", "PermissionsReady", "TopicPermissionsReady", "LimitsReady").
 		Webhook().
 		Complete()
 }
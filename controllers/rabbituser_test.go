@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 
 	cu "github.com/coderanger/controller-utils"
 	"github.com/coderanger/controller-utils/randstring"
@@ -27,6 +28,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 
@@ -57,7 +59,7 @@ var _ = Describe("RabbitUser controller", func() {
 			ObjectMeta: metav1.ObjectMeta{Name: "testing"},
 			Spec: rabbitv1beta1.RabbitUserSpec{
 				Username: "testing-" + randstring.MustRandomString(5),
-				Tags:     "management",
+				TagList:  []string{"management"},
 			},
 		}
 		c.Create(user)
@@ -96,7 +98,7 @@ var _ = Describe("RabbitUser controller", func() {
 			ObjectMeta: metav1.ObjectMeta{Name: "testing"},
 			Spec: rabbitv1beta1.RabbitUserSpec{
 				Username: "testing-" + randstring.MustRandomString(5),
-				Tags:     "administrator",
+				TagList:  []string{"administrator"},
 				Permissions: []rabbitv1beta1.RabbitPermission{
 					{
 						Vhost:     "/",
@@ -122,6 +124,46 @@ var _ = Describe("RabbitUser controller", func() {
 		Expect(vhosts).ToNot(BeEmpty())
 	})
 
+	It("sets topic permissions", func() {
+		c := helper.TestClient
+
+		user := &rabbitv1beta1.RabbitUser{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing"},
+			Spec: rabbitv1beta1.RabbitUserSpec{
+				Username: "testing-" + randstring.MustRandomString(5),
+				TopicPermissions: []rabbitv1beta1.RabbitTopicPermission{
+					{
+						Vhost:    "/",
+						Exchange: "amq.topic",
+						Write:    "^orders\\.",
+						Read:     ".*",
+					},
+				},
+			},
+		}
+		c.Create(user)
+		c.EventuallyGetName("testing", user, c.EventuallyReady())
+
+		perms, err := rmqc.ListTopicPermissionsOf(user.Spec.Username)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(perms).To(ConsistOf(rabbithole.TopicPermissionInfo{
+			User:     user.Spec.Username,
+			Vhost:    "/",
+			Exchange: "amq.topic",
+			Write:    "^orders\\.",
+			Read:     ".*",
+		}))
+
+		// Remove the topic permissions and make sure they are cleaned up.
+		user.Spec.TopicPermissions = nil
+		c.Update(user)
+		Eventually(func() []rabbithole.TopicPermissionInfo {
+			perms, err := rmqc.ListTopicPermissionsOf(user.Spec.Username)
+			Expect(err).ToNot(HaveOccurred())
+			return perms
+		}).Should(BeEmpty())
+	})
+
 	It("sets vhost permissions on a newly created vhost when using * permissions", func() {
 		c := helper.TestClient
 
@@ -137,7 +179,7 @@ var _ = Describe("RabbitUser controller", func() {
 			ObjectMeta: metav1.ObjectMeta{Name: "testing"},
 			Spec: rabbitv1beta1.RabbitUserSpec{
 				Username: "testing-" + randstring.MustRandomString(5),
-				Tags:     "administrator",
+				TagList:  []string{"administrator"},
 				Permissions: []rabbitv1beta1.RabbitPermission{
 					{
 						Vhost:     "*",
@@ -174,4 +216,83 @@ var _ = Describe("RabbitUser controller", func() {
 		_, err = rmqcUser.GetVhost(vhost.Spec.VhostName)
 		Expect(err).ToNot(HaveOccurred())
 	})
+
+	It("writes extra secret formats", func() {
+		c := helper.TestClient
+
+		user := &rabbitv1beta1.RabbitUser{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing"},
+			Spec: rabbitv1beta1.RabbitUserSpec{
+				Username:      "testing-" + randstring.MustRandomString(5),
+				SecretFormats: []string{"spring", "json", "amqpURL"},
+				Permissions: []rabbitv1beta1.RabbitPermission{
+					{
+						Vhost: "/",
+						Read:  ".*",
+					},
+				},
+			},
+		}
+		c.Create(user)
+		c.EventuallyGetName("testing", user, c.EventuallyReady())
+
+		secret := &corev1.Secret{}
+		c.GetName("testing-rabbituser", secret)
+		Expect(secret.Data).To(HaveKeyWithValue("RABBIT_PASSWORD", Not(BeEmpty())))
+		Expect(secret.Data).ToNot(HaveKey("RABBIT_URL"))
+		Expect(secret.Data).To(HaveKeyWithValue("spring.rabbitmq.username", []byte(user.Spec.Username)))
+		Expect(secret.Data).To(HaveKeyWithValue("spring.rabbitmq.password", secret.Data["RABBIT_PASSWORD"]))
+		Expect(secret.Data).To(HaveKeyWithValue("spring.rabbitmq.port", []byte("5672")))
+		Expect(secret.Data).To(HaveKeyWithValue("spring.rabbitmq.virtual-host", []byte("/")))
+		Expect(secret.Data).To(HaveKeyWithValue("AMQP_URL", HaveSuffix("/")))
+
+		blob := map[string]string{}
+		err := json.Unmarshal(secret.Data["rabbitmq.json"], &blob)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blob).To(HaveKeyWithValue("username", user.Spec.Username))
+		Expect(blob).To(HaveKeyWithValue("password", string(secret.Data["RABBIT_PASSWORD"])))
+		Expect(blob).To(HaveKeyWithValue("vhost", "/"))
+	})
+
+	It("renders a secret template from a configmap", func() {
+		c := helper.TestClient
+
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "templates"},
+			Data: map[string]string{"secret.yml": `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .Object.Name }}-custom
+stringData:
+  BROKER_USER: {{ .Data.username | quote }}
+  BROKER_PASSWORD: {{ .Data.RABBIT_PASSWORD | quote }}
+`},
+		}
+		c.Create(configMap)
+
+		user := &rabbitv1beta1.RabbitUser{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing"},
+			Spec: rabbitv1beta1.RabbitUserSpec{
+				Username:          "testing-" + randstring.MustRandomString(5),
+				SecretTemplateRef: &rabbitv1beta1.ConfigMapRef{Name: "templates"},
+			},
+		}
+		c.Create(user)
+		c.EventuallyGetName("testing", user, c.EventuallyReady())
+
+		secret := &corev1.Secret{}
+		c.GetName("testing-rabbituser", secret)
+		custom := &corev1.Secret{}
+		c.EventuallyGetName("testing-custom", custom)
+		Expect(custom.Data).To(HaveKeyWithValue("BROKER_USER", []byte(user.Spec.Username)))
+		Expect(custom.Data).To(HaveKeyWithValue("BROKER_PASSWORD", secret.Data["RABBIT_PASSWORD"]))
+
+		// Changing the template should be picked up through the watch.
+		configMap.Data["secret.yml"] += "  BROKER_EXTRA: extra\n"
+		c.Update(configMap)
+		c.EventuallyGetName("testing-custom", custom, c.EventuallyValue(HaveKeyWithValue("BROKER_EXTRA", []byte("extra")), func(obj runtime.Object) (interface{}, error) {
+			return obj.(*corev1.Secret).Data, nil
+		}))
+	})
 })
@@ -35,8 +35,10 @@ func RabbitVhost(mgr ctrl.Manager) error {
 		Templates(templates.Templates).
 		Component("vhost", components.Vhost()).
 		Component("policies", components.Policies()).
+		Component("operatorpolicies", components.OperatorPolicies()).
+		Component("limits", components.VhostLimits()).
 		TemplateComponent("vhost_user.yml", "UserReady").
-		ReadyStatusComponent("VhostReady", "PoliciesReady", "UserReady").
+		ReadyStatusComponent("VhostReady", "PoliciesReady", "OperatorPoliciesReady", "LimitsReady", "UserReady").
 		Webhook().
 		Complete()
 }
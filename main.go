@@ -52,10 +52,21 @@ func main() {
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&rabbitmqv1beta1.DefaultQueueNameTemplate, "queue-name-template", "",
+		"Go template for RabbitQueue names without a queueName or queueNameTemplate, e.g. {{ .Namespace }}.{{ .Name }}. "+
+			"Defaults to the object name.")
+	flag.StringVar(&rabbitmqv1beta1.DefaultHashingAlgorithm, "default-hashing-algorithm", "sha256",
+		"Password hashing algorithm for RabbitUsers without a hashingAlgorithm: sha256, sha512, or md5.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
+	err := rabbitmqv1beta1.ValidateHashingAlgorithm(rabbitmqv1beta1.DefaultHashingAlgorithm)
+	if err != nil {
+		setupLog.Error(err, "invalid --default-hashing-algorithm")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
@@ -69,7 +80,12 @@ func main() {
 	}
 
 	controllers := []func(ctrl.Manager) error{
+		controllers.RabbitBinding,
+		controllers.RabbitCluster,
+		controllers.RabbitExchange,
+		controllers.RabbitFederationUpstream,
 		controllers.RabbitQueue,
+		controllers.RabbitShovel,
 		controllers.RabbitUser,
 		controllers.RabbitVhost,
 	}